@@ -0,0 +1,187 @@
+// Package app is composition root of the service:
+// it wires config, pool, repositories, usecases and handlers and owns their lifecycle.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ansakharov/lets_test/cmd/config"
+	"github.com/ansakharov/lets_test/database"
+	"github.com/ansakharov/lets_test/handler"
+	change_order_status_handler "github.com/ansakharov/lets_test/handler/change_order_status"
+	create_order_handler "github.com/ansakharov/lets_test/handler/create_order"
+	get_orders_handler "github.com/ansakharov/lets_test/handler/get_orders"
+	health_handler "github.com/ansakharov/lets_test/handler/health"
+	item_handler "github.com/ansakharov/lets_test/handler/items"
+	metrics_handler "github.com/ansakharov/lets_test/handler/metrics"
+	idempotencyUCase "github.com/ansakharov/lets_test/internal/app/usecase/idempotency"
+	itemUCase "github.com/ansakharov/lets_test/internal/app/usecase/item"
+	orderUCase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	promoUCase "github.com/ansakharov/lets_test/internal/app/usecase/promo"
+	idempotencyRepo "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency"
+	itemRepo "github.com/ansakharov/lets_test/internal/pkg/repository/item"
+	orderRepo "github.com/ansakharov/lets_test/internal/pkg/repository/order"
+	promoRepo "github.com/ansakharov/lets_test/internal/pkg/repository/promo"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/ansakharov/lets_test/migration"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// Repositories used by usecases, tests pass fakes.
+type Repositories struct {
+	Orders orderRepo.OrderRepo
+	Items  itemRepo.ItemRepo
+	Promos promoRepo.PromoRepo
+	Keys   idempotencyRepo.KeyRepo
+}
+
+// PgRepositories gives repositories backed by pool, order calls are timed into m.
+func PgRepositories(pool *pgxpool.Pool, m metrics.Metrics) Repositories {
+	return Repositories{
+		Orders: orderRepo.NewTimed(orderRepo.New(pool), m),
+		Items:  itemRepo.New(pool),
+		Promos: promoRepo.New(pool),
+		Keys:   idempotencyRepo.New(pool),
+	}
+}
+
+// NewHandler builds usecases and handlers over repos and gives router serving them.
+func NewHandler(
+	log logrus.FieldLogger,
+	conf *config.Config,
+	repos Repositories,
+	health *health_handler.Handler,
+	registry *metrics.Registry,
+) http.Handler {
+	orders := orderUCase.New(repos.Orders, repos.Items, promoUCase.New(repos.Promos), registry)
+	keys := idempotencyUCase.New(repos.Keys, conf.Orders.IdempotencyTTL, conf.Orders.IdempotencyLease)
+	pageSize := get_orders_handler.PageSize{
+		Default: conf.Orders.DefaultPageSize,
+		Max:     conf.Orders.MaxPageSize,
+	}
+
+	return handler.Router(log, conf.HTTP, registry, handler.Handlers{
+		Health:      health,
+		Metrics:     metrics_handler.New(registry.Registry(), log),
+		CreateOrder: create_order_handler.New(orders, keys, log),
+		GetOrders:   get_orders_handler.New(orders, pageSize, log),
+		OrderStatus: change_order_status_handler.New(orders, log),
+		Items:       item_handler.New(itemUCase.New(repos.Items), log),
+	})
+}
+
+// App is http service with its resources.
+type App struct {
+	log      logrus.FieldLogger
+	conf     *config.Config
+	pool     *pgxpool.Pool
+	registry *metrics.Registry
+	health   *health_handler.Handler
+	srv      *http.Server
+	errCh    chan error
+}
+
+// New connects to database, applies migrations if configured and builds server.
+// Resources are released by Stop, or right away if New fails.
+func New(ctx context.Context, log logrus.FieldLogger, conf *config.Config) (*App, error) {
+	registry := metrics.New()
+	pool, err := database.Connect(ctx, log, conf.DbConnString, conf.Database, registry)
+	if err != nil {
+		return nil, fmt.Errorf("can't create pg pool: %s", err.Error())
+	}
+	database.RegisterStats(registry.Registry(), pool)
+
+	if conf.Migrations.AutoRun {
+		if err := migrate(ctx, log, pool); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("can't apply migrations: %w", err)
+		}
+	}
+
+	health := health_handler.New(map[string]health_handler.CheckFunc{
+		"postgres":   health_handler.PoolCheck(pool),
+		"migrations": health_handler.MigrationsCheck(pool, migration.Version),
+	}, log)
+
+	return &App{
+		log:      log,
+		conf:     conf,
+		pool:     pool,
+		registry: registry,
+		health:   health,
+		srv: &http.Server{
+			Addr:              conf.AppPort,
+			Handler:           NewHandler(log, conf, PgRepositories(pool, registry), health, registry),
+			ReadTimeout:       conf.HTTP.ReadTimeout,
+			ReadHeaderTimeout: conf.HTTP.ReadHeaderTimeout,
+			WriteTimeout:      conf.HTTP.WriteTimeout,
+			IdleTimeout:       conf.HTTP.IdleTimeout,
+		},
+		errCh: make(chan error, 1),
+	}, nil
+}
+
+// migrate applies pending embedded migrations.
+func migrate(ctx context.Context, log logrus.FieldLogger, pool *pgxpool.Pool) error {
+	migrations, err := migration.Embedded()
+	if err != nil {
+		return err
+	}
+	return migration.New(pool, migrations, log).Up(ctx)
+}
+
+// Start listens on configured port and serves in background.
+// Failure of serving is reported by Err.
+func (a *App) Start() error {
+	ln, err := net.Listen("tcp", a.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("can't listen: %s", err.Error())
+	}
+
+	go func() {
+		if err := a.srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			a.errCh <- fmt.Errorf("can't serve: %s", err.Error())
+		}
+		close(a.errCh)
+	}()
+	a.log.Print("The service is ready to listen and serve.")
+
+	return nil
+}
+
+// Err gives channel receiving error if server stops by itself.
+// It's closed once server is stopped.
+func (a *App) Err() <-chan error {
+	return a.errCh
+}
+
+// Stop makes readiness fail, waits for in-flight requests within timeout
+// and releases resources in reverse order.
+func (a *App) Stop() error {
+	// stop receiving new traffic first.
+	a.log.Print("Shutting down the service...")
+	a.health.Shutdown()
+	time.Sleep(a.conf.HTTP.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.conf.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	err := a.srv.Shutdown(shutdownCtx)
+	if err != nil {
+		err = fmt.Errorf("can't shutdown server gracefully: %s", err.Error())
+	}
+
+	// server doesn't use pool anymore.
+	a.pool.Close()
+	a.log.Print("Pg pool closed.")
+	a.registry.Flush(a.log)
+	a.log.Print("The service is stopped.")
+
+	return err
+}
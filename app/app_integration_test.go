@@ -0,0 +1,154 @@
+package app_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/app"
+	"github.com/ansakharov/lets_test/cmd/config"
+	health_handler "github.com/ansakharov/lets_test/handler/health"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	fake_idempotency "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency/fake_idempotency_repo"
+	fake_item "github.com/ansakharov/lets_test/internal/pkg/repository/item/fake_item_repo"
+	fake_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/fake_order_repo"
+	fake_promo "github.com/ansakharov/lets_test/internal/pkg/repository/promo/fake_promo_repo"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPStack(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	items := fake_item.New()
+	require.NoError(t, items.Save(ctx, log, &item.Item{Name: "premium", Price: 100}))
+	orders := fake_order.New()
+	orders.Now = func() time.Time { return time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	conf := &config.Config{
+		Orders: config.Orders{
+			IdempotencyTTL:   time.Hour,
+			IdempotencyLease: time.Minute,
+			DefaultPageSize:  10,
+			MaxPageSize:      100,
+		},
+		HTTP: config.HTTP{
+			RequestTimeout: time.Second,
+			AccessLog:      config.AccessLog{SampleRate: 1},
+		},
+	}
+	repos := app.Repositories{
+		Orders: orders,
+		Items:  items,
+		Promos: fake_promo.New(),
+		Keys:   fake_idempotency.New(),
+	}
+	health := health_handler.New(map[string]health_handler.CheckFunc{}, log)
+	srv := httptest.NewServer(app.NewHandler(log, conf, repos, health, metrics.New()))
+	defer srv.Close()
+
+	cases := []struct {
+		name    string
+		method  string
+		url     string
+		body    string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "create_order",
+			method:  http.MethodPost,
+			url:     "/order",
+			body:    `{"user_id":1,"payment_type":"card","items":[{"id":1,"quantity":2}]}`,
+			expCode: http.StatusCreated,
+			expBody: `{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":200,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":1,"quantity":2,"amount":100,"discounted_amount":0}]}` + "\n",
+		},
+		{
+			name:    "create_order_unknown_item",
+			method:  http.MethodPost,
+			url:     "/order",
+			body:    `{"user_id":1,"payment_type":"card","items":[{"id":7,"quantity":1}]}`,
+			expCode: http.StatusUnprocessableEntity,
+			expBody: `{"code":"unprocessable","message":"unknown items: [7]","request_id":"test-request"}` + "\n",
+		},
+		{
+			name:    "create_order_bad_request",
+			method:  http.MethodPost,
+			url:     "/order",
+			body:    `{"payment_type":"cash","items":[]}`,
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid user ID","invalid payment type","items can't be empty"],"request_id":"test-request"}` + "\n",
+		},
+		{
+			name:    "process_order",
+			method:  http.MethodPost,
+			url:     "/order/1/process",
+			expCode: http.StatusOK,
+			expBody: `{"id":1,"status":"processed","user_id":1,"payment_type":"card","original_amount":200,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":1,"quantity":2,"amount":100,"discounted_amount":0}]}` + "\n",
+		},
+		{
+			name:    "get_orders",
+			method:  http.MethodGet,
+			url:     "/orders?ids=1,2",
+			expCode: http.StatusOK,
+			expBody: `{"orders":[{"id":1,"status":"processed","user_id":1,"payment_type":"card","original_amount":200,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":1,"quantity":2,"amount":100,"discounted_amount":0}]}],"not_found":[2]}` + "\n",
+		},
+		{
+			name:    "get_missing_order",
+			method:  http.MethodGet,
+			url:     "/order/2",
+			expCode: http.StatusNotFound,
+			expBody: `{"code":"not_found","message":"order not found","request_id":"test-request"}` + "\n",
+		},
+		{
+			name:    "list_items",
+			method:  http.MethodGet,
+			url:     "/items",
+			expCode: http.StatusOK,
+			expBody: `[{"id":1,"name":"premium","price":100}]` + "\n",
+		},
+		{
+			name:    "healthz",
+			method:  http.MethodGet,
+			url:     "/healthz",
+			expCode: http.StatusOK,
+			expBody: `{"status":"ok"}` + "\n",
+		},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			req, err := http.NewRequest(tCase.method, srv.URL+tCase.url, strings.NewReader(tCase.body))
+			require.NoError(t, err)
+			req.Header.Set("X-Request-ID", "test-request")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, resp.StatusCode)
+			require.Equal(t, tCase.expBody, string(body))
+			require.Equal(t, "test-request", resp.Header.Get("X-Request-ID"))
+		})
+	}
+
+	t.Run("metrics", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, string(body), `http_requests_total{route="/order",method="POST",status="201"} 1`)
+		require.Contains(t, string(body), "save_order_ok 1")
+	})
+}
@@ -1,16 +1,119 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"time"
 
+	"github.com/ansakharov/lets_test/database"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/logger"
 	"gopkg.in/yaml.v3"
 )
 
+// Idempotency key lifetimes used when they aren't set.
+const (
+	defaultIdempotencyTTL   = 24 * time.Hour
+	defaultIdempotencyLease = time.Minute
+)
+
+// createOrderRoute is name of POST /order in http.route_timeouts.
+const createOrderRoute = "create_order"
+
+// Page sizes of GET /orders used when they aren't set.
+const (
+	defaultPageSize    = 50
+	defaultMaxPageSize = 500
+)
+
+// Http server timeouts used when they aren't set.
+const (
+	defaultReadTimeout       = 10 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultShutdownTimeout   = 30 * time.Second
+	defaultRequestTimeout    = 10 * time.Second
+)
+
+// Database connect retries used when they aren't set.
+const (
+	defaultConnectAttempts   = 5
+	defaultConnectBackoff    = 500 * time.Millisecond
+	defaultMaxConnectBackoff = 10 * time.Second
+)
+
+// defaultAccessLogSampleRate is used when http.access_log.sample_rate isn't set.
+const defaultAccessLogSampleRate = 1
+
+// Config of service.
+// Every setting can be overridden by environment variable, see applyEnv.
 type Config struct {
-	AppPort      string `yaml:"port"`
-	DbConnString string `yaml:"db_conn_string"`
+	AppPort      string          `yaml:"port" env:"APP_PORT"`
+	DbConnString string          `yaml:"db_conn_string"`
+	Database     database.Config `yaml:"database"`
+	Orders       Orders          `yaml:"orders"`
+	HTTP         HTTP            `yaml:"http"`
+	Log          logger.Config   `yaml:"log"`
+	Migrations   Migrations      `yaml:"migrations"`
+}
+
+// Migrations contains settings of schema migrations.
+type Migrations struct {
+	// AutoRun applies pending migrations on start of service.
+	AutoRun bool `yaml:"auto_run"`
+}
+
+// HTTP contains settings of http server.
+type HTTP struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownDelay is how long service keeps serving after readiness starts failing,
+	// so orchestrator stops routing traffic before server is shut down.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// ShutdownTimeout is how long in-flight requests are waited for on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// RequestTimeout limits handling of request, RouteTimeouts override it by route name.
+	RequestTimeout time.Duration            `yaml:"request_timeout"`
+	RouteTimeouts  map[string]time.Duration `yaml:"route_timeouts"`
+	AccessLog      AccessLog                `yaml:"access_log"`
+}
+
+// RouteTimeout gives timeout of route by name, RequestTimeout if route isn't overridden.
+func (h HTTP) RouteTimeout(name string) time.Duration {
+	if timeout, ok := h.RouteTimeouts[name]; ok {
+		return timeout
+	}
+	return h.RequestTimeout
+}
+
+// AccessLog contains settings of access log.
+type AccessLog struct {
+	// SampleRate is share of requests logged, from 0 to 1. Server errors are logged always.
+	SampleRate float64 `yaml:"sample_rate"`
+	// Exclude lists route path templates which aren't logged, e.g. health checks.
+	Exclude []string `yaml:"exclude"`
+}
+
+// Orders contains settings of orders API.
+type Orders struct {
+	// IdempotencyTTL is how long results of POST /order are kept for replays.
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+	// IdempotencyLease is how long key of unfinished POST /order is held,
+	// so key abandoned by crashed request can be retried. It must exceed timeout of create_order route.
+	IdempotencyLease time.Duration `yaml:"idempotency_lease"`
+	// DefaultPageSize is number of orders listed when client doesn't pass limit.
+	DefaultPageSize uint64 `yaml:"default_page_size"`
+	// MaxPageSize is the biggest limit client can ask for.
+	MaxPageSize uint64 `yaml:"max_page_size"`
 }
 
 func Parse(confPath string) (*Config, error) {
@@ -23,11 +126,128 @@ func Parse(confPath string) (*Config, error) {
 		return nil, fmt.Errorf("can't read conf: %s", err.Error())
 	}
 
-	var config Config
-	err = yaml.Unmarshal(yamlConf, &config)
-	if err != nil {
+	// zero sample rate turns sampling off, so its default is set before decoding
+	// and is kept only when the key is absent.
+	config := Config{HTTP: HTTP{AccessLog: AccessLog{SampleRate: defaultAccessLogSampleRate}}}
+	dec := yaml.NewDecoder(bytes.NewReader(yamlConf))
+	// typo in key must not silently leave setting default.
+	dec.KnownFields(true)
+	if err := dec.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("can't unmarshall conf: %s", err.Error())
 	}
 
+	if errs := applyEnv(&config); len(errs) > 0 {
+		return nil, apperr.NewValidation("invalid env", errs...)
+	}
+	setDefaults(&config)
+	if err := validate(&config); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
+
+// validate checks config, all failures are listed in returned error.
+func validate(config *Config) error {
+	var errs []error
+	if config.AppPort == "" {
+		errs = append(errs, errors.New("port is required"))
+	}
+	if config.DbConnString == "" {
+		errs = append(errs, errors.New("db_conn_string is required"))
+	}
+	if rate := config.HTTP.AccessLog.SampleRate; rate < 0 || rate > 1 {
+		errs = append(errs, fmt.Errorf("http.access_log.sample_rate %v must be from 0 to 1", rate))
+	}
+	if config.Orders.DefaultPageSize > config.Orders.MaxPageSize {
+		errs = append(errs, fmt.Errorf(
+			"orders.default_page_size %d exceeds orders.max_page_size %d",
+			config.Orders.DefaultPageSize,
+			config.Orders.MaxPageSize,
+		))
+	}
+	if config.Orders.IdempotencyLease > config.Orders.IdempotencyTTL {
+		errs = append(errs, fmt.Errorf(
+			"orders.idempotency_lease %s exceeds orders.idempotency_ttl %s",
+			config.Orders.IdempotencyLease,
+			config.Orders.IdempotencyTTL,
+		))
+	}
+	if lease, timeout := config.Orders.IdempotencyLease, config.HTTP.RouteTimeout(createOrderRoute); lease <= timeout {
+		errs = append(errs, fmt.Errorf(
+			"orders.idempotency_lease %s must exceed %s timeout %s",
+			lease,
+			createOrderRoute,
+			timeout,
+		))
+	}
+	errs = append(errs, config.Database.Validate()...)
+	errs = append(errs, config.Log.Validate()...)
+
+	return apperr.NewValidation("invalid config", errs...)
+}
+
+// String gives config for logs, password in DbConnString is redacted.
+func (c Config) String() string {
+	// plain has no String method, so formatting doesn't recurse.
+	type plain Config
+	redacted := plain(c)
+	redacted.DbConnString = redactConnString(c.DbConnString)
+	return fmt.Sprintf("%+v", redacted)
+}
+
+// keywordPassword matches password of keyword/value connection string.
+var keywordPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactConnString hides password of url or keyword/value connection string.
+func redactConnString(conn string) string {
+	if u, err := url.Parse(conn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return keywordPassword.ReplaceAllString(conn, "${1}xxxxx")
+}
+
+// setDefaults fills settings which aren't set in config file.
+func setDefaults(config *Config) {
+	if config.Orders.IdempotencyTTL == 0 {
+		config.Orders.IdempotencyTTL = defaultIdempotencyTTL
+	}
+	if config.Orders.IdempotencyLease == 0 {
+		config.Orders.IdempotencyLease = defaultIdempotencyLease
+	}
+	if config.Orders.MaxPageSize == 0 {
+		config.Orders.MaxPageSize = defaultMaxPageSize
+	}
+	if config.Orders.DefaultPageSize == 0 {
+		config.Orders.DefaultPageSize = defaultPageSize
+	}
+
+	if config.Database.ConnectAttempts == 0 {
+		config.Database.ConnectAttempts = defaultConnectAttempts
+	}
+	if config.Database.ConnectBackoff == 0 {
+		config.Database.ConnectBackoff = defaultConnectBackoff
+	}
+	if config.Database.MaxConnectBackoff == 0 {
+		config.Database.MaxConnectBackoff = defaultMaxConnectBackoff
+	}
+
+	if config.HTTP.ReadTimeout == 0 {
+		config.HTTP.ReadTimeout = defaultReadTimeout
+	}
+	if config.HTTP.ReadHeaderTimeout == 0 {
+		config.HTTP.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+	if config.HTTP.WriteTimeout == 0 {
+		config.HTTP.WriteTimeout = defaultWriteTimeout
+	}
+	if config.HTTP.IdleTimeout == 0 {
+		config.HTTP.IdleTimeout = defaultIdleTimeout
+	}
+	if config.HTTP.ShutdownTimeout == 0 {
+		config.HTTP.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if config.HTTP.RequestTimeout == 0 {
+		config.HTTP.RequestTimeout = defaultRequestTimeout
+	}
+}
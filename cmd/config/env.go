@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileSuffix marks variable naming file which holds value, e.g. DB_CONN_STRING_FILE=/run/secrets/db.
+const fileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides fields of config by environment variables.
+// Variable name is env tag of field, otherwise prefix and upper-cased yaml key joined by underscore,
+// e.g. HTTP_ACCESS_LOG_SAMPLE_RATE. Lists are comma separated, maps are like key=value,key=value.
+// All malformed variables are listed in returned errors.
+func applyEnv(config *Config) []error {
+	return applyEnvTo(reflect.ValueOf(config).Elem(), "")
+}
+
+func applyEnvTo(v reflect.Value, prefix string) []error {
+	var errs []error
+	for idx := 0; idx < v.NumField(); idx++ {
+		field := v.Type().Field(idx)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		name := field.Tag.Get("env")
+		if name == "" {
+			name = strings.ToUpper(key)
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+		}
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			errs = append(errs, applyEnvTo(v.Field(idx), name)...)
+			continue
+		}
+
+		value, ok, err := lookupEnv(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(v.Field(idx), value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// lookupEnv gives value of variable name or content of file named by name_FILE.
+func lookupEnv(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	path, fromFile := os.LookupEnv(name + fileSuffix)
+	if !fromFile {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("both %s and %s%s are set", name, name, fileSuffix)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("can't read %s%s: %w", name, fileSuffix, err)
+	}
+	// files usually end with newline, it's never part of secret.
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// setValue parses value into field of supported kind.
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, part := range splitList(value) {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(elem, part); err != nil {
+				return err
+			}
+			list = reflect.Append(list, elem)
+		}
+		field.Set(list)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		for _, part := range splitList(value) {
+			pair := strings.SplitN(part, "=", 2)
+			if len(pair) != 2 {
+				return fmt.Errorf("%q isn't key=value", part)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(pair[1])); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(pair[0])), elem)
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// splitList splits comma separated value, empty value is empty list.
+func splitList(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
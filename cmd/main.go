@@ -4,16 +4,42 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ansakharov/lets_test/cmd/config"
-	"github.com/ansakharov/lets_test/handler"
+	"github.com/ansakharov/lets_test/database"
 	"github.com/ansakharov/lets_test/logger"
 	"github.com/ansakharov/lets_test/metrics"
-	_ "github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/sirupsen/logrus"
 )
 
+// command runs single subcommand of binary, args go after its name.
+type command func(ctx context.Context, log logrus.FieldLogger, conf *config.Config, args []string) error
+
+// commands by name, binary without command serves http.
+var commands = map[string]command{
+	"serve":   runServe,
+	"migrate": runMigrate,
+	"config":  runConfig,
+	"orders":  runOrders,
+	"seed":    runSeed,
+}
+
+const usage = `usage: app --conf=conf.yaml [command]
+
+commands:
+  serve                        run http server, default
+  migrate up|down|status|to N  apply or revert schema migrations
+  migrate baseline N           record migrations up to N as applied without running them
+  config validate              check config file
+  orders get <id>...           print orders
+  orders create --file FILE    create order from json file, - reads stdin
+  seed                         add missing catalog items
+`
+
 func main() {
 	// Get logger interface.
 	log := logger.New()
@@ -23,14 +49,26 @@ func main() {
 	}
 }
 
-//
+// mainNoExit parses config and runs command given in args.
 func mainNoExit(log logrus.FieldLogger) error {
-	metrics.Init()
-
 	// get application config
 	confFlag := flag.String("conf", "", "config yaml file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+
 	confString := *confFlag
 	if confString == "" {
 		return fmt.Errorf(" 'conf' flag required")
@@ -40,18 +78,29 @@ func mainNoExit(log logrus.FieldLogger) error {
 		return err
 	}
 
-	log.Println(config)
-	log.Println("Starting the service...")
-
-	ctx := context.Background()
-	router, err := handler.Router(ctx, log, config)
+	log, err = logger.FromConfig(config.Log)
 	if err != nil {
-		return fmt.Errorf("can't init router: %s", err.Error())
+		return err
 	}
 
-	log.Print("The service is ready to listen and serve.")
-	return http.ListenAndServe(
-		config.AppPort, //port,
-		router,
-	)
+	// stop on SIGINT or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return cmd(ctx, log, config, args)
+}
+
+// runConfig runs config subcommands.
+func runConfig(_ context.Context, _ logrus.FieldLogger, conf *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "validate" {
+		return fmt.Errorf("usage: config validate")
+	}
+	// config is parsed and validated before command runs.
+	fmt.Fprintln(os.Stdout, "config is valid")
+	return nil
+}
+
+// connect gives pool of database from config, connection is retried per database settings.
+func connect(ctx context.Context, log logrus.FieldLogger, conf *config.Config, m metrics.Metrics) (*pgxpool.Pool, error) {
+	return database.Connect(ctx, log, conf.DbConnString, conf.Database, m)
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ansakharov/lets_test/cmd/config"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/ansakharov/lets_test/migration"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// errMigrateUsage lists subcommands of migrate.
+var errMigrateUsage = errors.New("usage: migrate up|down|status|to N|baseline N")
+
+// runMigrate runs migrate subcommand.
+func runMigrate(ctx context.Context, log logrus.FieldLogger, conf *config.Config, args []string) error {
+	pool, err := connect(ctx, log, conf, metrics.Nop{})
+	if err != nil {
+		return fmt.Errorf("can't create pg pool: %s", err.Error())
+	}
+	defer pool.Close()
+
+	return migrate(ctx, log, pool, args)
+}
+
+// migrate applies or reverts migrations, args go after "migrate".
+func migrate(ctx context.Context, log logrus.FieldLogger, pool *pgxpool.Pool, args []string) error {
+	migrations, err := migration.Embedded()
+	if err != nil {
+		return err
+	}
+	migrator := migration.New(pool, migrations, log)
+
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	switch cmd := args[0]; {
+	case cmd == "up" && len(args) == 1:
+		return migrator.Up(ctx)
+	case cmd == "down" && len(args) == 1:
+		return migrator.Down(ctx)
+	case (cmd == "to" || cmd == "baseline") && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("bad version %q, %w", args[1], errMigrateUsage)
+		}
+		if cmd == "baseline" {
+			return migrator.Baseline(ctx, version)
+		}
+		return migrator.To(ctx, version)
+	case cmd == "status" && len(args) == 1:
+		report, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(report)
+		return nil
+	default:
+		return errMigrateUsage
+	}
+}
+
+// printStatus writes table of migrations to stdout.
+func printStatus(report migration.Report) {
+	if report.TableMissing {
+		fmt.Println("schema_migrations table is missing, nothing is recorded as applied")
+	}
+	fmt.Printf("%-8s %-24s %-10s %s\n", "VERSION", "NAME", "STATE", "APPLIED AT")
+	for _, status := range report.Migrations {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+		}
+		switch {
+		case status.Missing:
+			state = "missing"
+		case status.Modified:
+			state = "modified"
+		}
+		fmt.Printf("%-8d %-24s %-10s %s\n", status.Version, status.Name, state, appliedAt)
+	}
+}
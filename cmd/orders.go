@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ansakharov/lets_test/app"
+	"github.com/ansakharov/lets_test/cmd/config"
+	create_order_handler "github.com/ansakharov/lets_test/handler/create_order"
+	"github.com/ansakharov/lets_test/handler/dto"
+	get_orders_handler "github.com/ansakharov/lets_test/handler/get_orders"
+	orderUCase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	promoUCase "github.com/ansakharov/lets_test/internal/app/usecase/promo"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+var errOrdersUsage = errors.New("usage: orders get <id>... | orders create --file FILE")
+
+// runOrders runs orders subcommands, results are printed to stdout as json.
+func runOrders(ctx context.Context, log logrus.FieldLogger, conf *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errOrdersUsage
+	}
+
+	var run func(ctx context.Context, log logrus.FieldLogger, uCase *orderUCase.Usecase) error
+	switch args[0] {
+	case "get":
+		IDs, err := parseIDs(args[1:])
+		if err != nil {
+			return err
+		}
+		run = func(ctx context.Context, log logrus.FieldLogger, uCase *orderUCase.Usecase) error {
+			return getOrders(ctx, log, uCase, IDs)
+		}
+	case "create":
+		in, err := readOrder(args[1:])
+		if err != nil {
+			return err
+		}
+		run = func(ctx context.Context, log logrus.FieldLogger, uCase *orderUCase.Usecase) error {
+			return createOrder(ctx, log, uCase, in)
+		}
+	default:
+		return errOrdersUsage
+	}
+
+	pool, err := connect(ctx, log, conf, metrics.Nop{})
+	if err != nil {
+		return fmt.Errorf("can't create pg pool: %s", err.Error())
+	}
+	defer pool.Close()
+
+	repos := app.PgRepositories(pool, metrics.Nop{})
+	uCase := orderUCase.New(repos.Orders, repos.Items, promoUCase.New(repos.Promos), metrics.Nop{})
+
+	return run(ctx, log, uCase)
+}
+
+// parseIDs parses order ids passed as args.
+func parseIDs(args []string) ([]uint64, error) {
+	if len(args) == 0 {
+		return nil, errOrdersUsage
+	}
+	IDs := make([]uint64, 0, len(args))
+	for _, arg := range args {
+		ID, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil || ID == 0 {
+			return nil, fmt.Errorf("invalid order id %q", arg)
+		}
+		IDs = append(IDs, ID)
+	}
+	return IDs, nil
+}
+
+// readOrder reads and validates order from file given by --file flag.
+func readOrder(args []string) (*create_order_handler.OrderIn, error) {
+	flags := flag.NewFlagSet("orders create", flag.ContinueOnError)
+	file := flags.String("file", "", "order json file, - reads stdin")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if *file == "" || flags.NArg() != 0 {
+		return nil, errOrdersUsage
+	}
+
+	var src io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return nil, fmt.Errorf("can't open order file: %w", err)
+		}
+		defer f.Close()
+		src = f
+	}
+
+	in := &create_order_handler.OrderIn{}
+	if err := json.NewDecoder(src).Decode(in); err != nil {
+		return nil, fmt.Errorf("can't parse order: %w", err)
+	}
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// getOrders prints orders in the same form as GET /orders?ids=.
+func getOrders(ctx context.Context, log logrus.FieldLogger, uCase *orderUCase.Usecase, IDs []uint64) error {
+	orders, notFound, err := uCase.Get(ctx, log, order.Filter{IDs: IDs})
+	if err != nil {
+		return fmt.Errorf("can't get orders: %w", err)
+	}
+	return printJSON(get_orders_handler.GetOrdersOut{Orders: dto.NewOrdersOut(orders), NotFound: &notFound})
+}
+
+// createOrder saves order and prints it in the same form as POST /order.
+func createOrder(ctx context.Context, log logrus.FieldLogger, uCase *orderUCase.Usecase, in *create_order_handler.OrderIn) error {
+	ord := in.OrderFromDTO()
+	if err := uCase.Save(ctx, log.WithField("user_id", in.UserID), &ord); err != nil {
+		return fmt.Errorf("can't create order: %w", err)
+	}
+	return printJSON(dto.NewOrderOut(ord))
+}
+
+// printJSON writes indented json to stdout.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ansakharov/lets_test/cmd/config"
+	itemUCase "github.com/ansakharov/lets_test/internal/app/usecase/item"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	itemRepo "github.com/ansakharov/lets_test/internal/pkg/repository/item"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// catalog is default set of items, the same rows the first migration inserts.
+var catalog = []item.Item{
+	{Name: "premium", Price: 100000},
+	{Name: "calltracking", Price: 20000},
+	{Name: "autoload", Price: 200000},
+	{Name: "limit", Price: 500000},
+}
+
+// runSeed adds catalog items missing by name, existing items are kept as is.
+func runSeed(ctx context.Context, log logrus.FieldLogger, conf *config.Config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: seed")
+	}
+
+	pool, err := connect(ctx, log, conf, metrics.Nop{})
+	if err != nil {
+		return fmt.Errorf("can't create pg pool: %s", err.Error())
+	}
+	defer pool.Close()
+
+	uCase := itemUCase.New(itemRepo.New(pool))
+	existing, err := uCase.List(ctx, log)
+	if err != nil {
+		return fmt.Errorf("can't list items: %w", err)
+	}
+	names := make(map[string]struct{}, len(existing))
+	for _, it := range existing {
+		names[it.Name] = struct{}{}
+	}
+
+	for _, it := range catalog {
+		if _, ok := names[it.Name]; ok {
+			continue
+		}
+		it := it
+		if err := uCase.Create(ctx, log, &it); err != nil {
+			return fmt.Errorf("can't create item %q: %w", it.Name, err)
+		}
+		log.WithField("item_id", it.ID).Printf("item %q created", it.Name)
+	}
+
+	return nil
+}
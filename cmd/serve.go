@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ansakharov/lets_test/app"
+	"github.com/ansakharov/lets_test/cmd/config"
+	"github.com/sirupsen/logrus"
+)
+
+// runServe runs http server until ctx is done.
+func runServe(ctx context.Context, log logrus.FieldLogger, conf *config.Config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: serve")
+	}
+
+	log.Println(conf)
+	log.Println("Starting the service...")
+
+	a, err := app.New(ctx, log, conf)
+	if err != nil {
+		return err
+	}
+	if err := a.Start(); err != nil {
+		stopErr := a.Stop()
+		if stopErr != nil {
+			log.WithError(stopErr).Error("can't stop the service")
+		}
+		return err
+	}
+
+	select {
+	case err := <-a.Err():
+		// server is already down, only resources are released.
+		if stopErr := a.Stop(); stopErr != nil {
+			log.WithError(stopErr).Error("can't stop the service")
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	return a.Stop()
+}
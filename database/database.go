@@ -0,0 +1,132 @@
+// Package database connects to postgres with pool settings from config.
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// Config contains settings of connection pool.
+// Zero pool settings keep pgxpool defaults.
+type Config struct {
+	// MaxConns limits number of connections in pool.
+	MaxConns int32 `yaml:"max_conns"`
+	// MinConns is number of connections kept open even when idle.
+	MinConns int32 `yaml:"min_conns"`
+	// MaxConnLifetime is how long connection is used before it's closed and replaced.
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	// HealthCheckPeriod is how often idle connections are checked.
+	HealthCheckPeriod time.Duration `yaml:"health_check_period"`
+	// StatementTimeout aborts queries running longer, zero disables it.
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+	// ApplicationName is shown in pg_stat_activity.
+	ApplicationName string `yaml:"application_name"`
+	// ConnectAttempts is how many times connection is tried on start.
+	ConnectAttempts uint64 `yaml:"connect_attempts"`
+	// ConnectBackoff is pause after the first failed attempt, it doubles after every next one.
+	ConnectBackoff time.Duration `yaml:"connect_backoff"`
+	// MaxConnectBackoff limits pause between attempts.
+	MaxConnectBackoff time.Duration `yaml:"max_connect_backoff"`
+}
+
+// Validate checks settings, all failures are listed in returned errors.
+func (c Config) Validate() []error {
+	var errs []error
+	if c.MaxConns < 0 || c.MinConns < 0 {
+		errs = append(errs, fmt.Errorf("database.min_conns and database.max_conns can't be negative"))
+	}
+	if c.MaxConns > 0 && c.MinConns > c.MaxConns {
+		errs = append(errs, fmt.Errorf("database.min_conns %d exceeds database.max_conns %d", c.MinConns, c.MaxConns))
+	}
+	if c.StatementTimeout < 0 {
+		errs = append(errs, fmt.Errorf("database.statement_timeout %s can't be negative", c.StatementTimeout))
+	}
+	if c.ConnectAttempts == 0 {
+		errs = append(errs, fmt.Errorf("database.connect_attempts must be positive"))
+	}
+	if c.ConnectBackoff > c.MaxConnectBackoff {
+		errs = append(errs, fmt.Errorf(
+			"database.connect_backoff %s exceeds database.max_connect_backoff %s",
+			c.ConnectBackoff,
+			c.MaxConnectBackoff,
+		))
+	}
+	return errs
+}
+
+// PoolConfig gives pgxpool config of connString tuned by conf.
+func PoolConfig(connString string, conf Config) (*pgxpool.Config, error) {
+	poolConf, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse db_conn_string: %w", err)
+	}
+
+	if conf.MaxConns > 0 {
+		poolConf.MaxConns = conf.MaxConns
+	}
+	if conf.MinConns > 0 {
+		poolConf.MinConns = conf.MinConns
+	}
+	if conf.MaxConnLifetime > 0 {
+		poolConf.MaxConnLifetime = conf.MaxConnLifetime
+	}
+	if conf.HealthCheckPeriod > 0 {
+		poolConf.HealthCheckPeriod = conf.HealthCheckPeriod
+	}
+
+	params := poolConf.ConnConfig.RuntimeParams
+	if conf.StatementTimeout > 0 {
+		// postgres takes milliseconds without unit.
+		params["statement_timeout"] = strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10)
+	}
+	if conf.ApplicationName != "" {
+		params["application_name"] = conf.ApplicationName
+	}
+
+	return poolConf, nil
+}
+
+// Connect creates pool and checks that database is reachable.
+// Failed attempts are retried with exponential backoff, so service survives short database outage on start.
+func Connect(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	connString string,
+	conf Config,
+	m metrics.Metrics,
+) (*pgxpool.Pool, error) {
+	poolConf, err := PoolConfig(connString, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := conf.ConnectBackoff
+	for attempt := uint64(1); ; attempt++ {
+		pool, err := pgxpool.ConnectConfig(ctx, poolConf)
+		if err == nil {
+			return pool, nil
+		}
+		if attempt >= conf.ConnectAttempts {
+			return nil, fmt.Errorf("can't connect after %d attempts: %w", attempt, err)
+		}
+
+		m.IncCounter(metrics.DBConnectRetries)
+		log.WithError(err).Warnf("can't connect to database, attempt %d, retry in %s", attempt, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("can't connect: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > conf.MaxConnectBackoff {
+			backoff = conf.MaxConnectBackoff
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolConfig(t *testing.T) {
+	poolConf, err := PoolConfig("postgres://user@localhost:5432/postgres", Config{
+		MaxConns:          10,
+		MinConns:          2,
+		MaxConnLifetime:   time.Hour,
+		HealthCheckPeriod: time.Minute,
+		StatementTimeout:  1500 * time.Millisecond,
+		ApplicationName:   "lets_test",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, int32(10), poolConf.MaxConns)
+	require.Equal(t, int32(2), poolConf.MinConns)
+	require.Equal(t, time.Hour, poolConf.MaxConnLifetime)
+	require.Equal(t, time.Minute, poolConf.HealthCheckPeriod)
+	require.Equal(t, "1500", poolConf.ConnConfig.RuntimeParams["statement_timeout"])
+	require.Equal(t, "lets_test", poolConf.ConnConfig.RuntimeParams["application_name"])
+}
+
+func TestPoolConfigKeepsDefaults(t *testing.T) {
+	defaults, err := PoolConfig("postgres://user@localhost:5432/postgres", Config{})
+	require.NoError(t, err)
+
+	require.Positive(t, defaults.MaxConns)
+	require.NotContains(t, defaults.ConnConfig.RuntimeParams, "statement_timeout")
+}
+
+func TestValidate(t *testing.T) {
+	valid := Config{ConnectAttempts: 1, ConnectBackoff: time.Second, MaxConnectBackoff: time.Second}
+	require.Empty(t, valid.Validate())
+
+	invalid := Config{MaxConns: 2, MinConns: 3, StatementTimeout: -time.Second, ConnectBackoff: time.Minute}
+	require.Len(t, invalid.Validate(), 4)
+}
+
+func TestConnectRetries(t *testing.T) {
+	recorder := metrics.NewRecorder()
+	conf := Config{
+		ConnectAttempts:   3,
+		ConnectBackoff:    time.Millisecond,
+		MaxConnectBackoff: 2 * time.Millisecond,
+	}
+
+	// nothing listens on port 1.
+	_, err := Connect(context.Background(), logrus.New(), "postgres://user@127.0.0.1:1/postgres?connect_timeout=1", conf, recorder)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "after 3 attempts")
+	require.Equal(t, int64(2), recorder.Count(metrics.DBConnectRetries))
+}
+
+func TestConnectStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	conf := Config{ConnectAttempts: 100, ConnectBackoff: time.Hour, MaxConnectBackoff: time.Hour}
+
+	_, err := Connect(ctx, logrus.New(), "postgres://user@127.0.0.1:1/postgres", conf, metrics.Nop{})
+	require.ErrorIs(t, err, context.Canceled)
+}
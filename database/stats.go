@@ -0,0 +1,34 @@
+package database
+
+import (
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/jackc/pgx/v4/pgxpool"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// RegisterStats exports stats of pool into registry.
+// Values are read from pool when registry is exported, so nothing has to be updated.
+func RegisterStats(registry gometrics.Registry, pool *pgxpool.Pool) {
+	stat := func(f func(s *pgxpool.Stat) int64) func() int64 {
+		return func() int64 { return f(pool.Stat()) }
+	}
+
+	gauges := map[string]func(s *pgxpool.Stat) int64{
+		metrics.Labeled(metrics.DBPoolConns, "state", "acquired"):     func(s *pgxpool.Stat) int64 { return int64(s.AcquiredConns()) },
+		metrics.Labeled(metrics.DBPoolConns, "state", "idle"):         func(s *pgxpool.Stat) int64 { return int64(s.IdleConns()) },
+		metrics.Labeled(metrics.DBPoolConns, "state", "constructing"): func(s *pgxpool.Stat) int64 { return int64(s.ConstructingConns()) },
+		metrics.DBPoolMaxConns: func(s *pgxpool.Stat) int64 { return int64(s.MaxConns()) },
+	}
+	for name, f := range gauges {
+		registry.GetOrRegister(name, gometrics.NewFunctionalGauge(stat(f)))
+	}
+
+	counters := map[string]func(s *pgxpool.Stat) int64{
+		metrics.DBPoolAcquires:         (*pgxpool.Stat).AcquireCount,
+		metrics.DBPoolEmptyAcquires:    (*pgxpool.Stat).EmptyAcquireCount,
+		metrics.DBPoolCanceledAcquires: (*pgxpool.Stat).CanceledAcquireCount,
+	}
+	for name, f := range counters {
+		registry.GetOrRegister(name, metrics.NewFunctionalCounter(stat(f)))
+	}
+}
@@ -0,0 +1,76 @@
+package order_handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ansakharov/lets_test/handler/dto"
+	"github.com/ansakharov/lets_test/handler/httperr"
+	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Requst validation errors.
+var ErrInvalidOrderID = errors.New("invalid order ID")
+
+// Handler changes status of orders.
+type Handler struct {
+	uCase *order_ucase.Usecase
+	log   logrus.FieldLogger
+}
+
+// New gives Handler.
+func New(
+	uCase *order_ucase.Usecase,
+	log logrus.FieldLogger,
+) *Handler {
+	return &Handler{
+		uCase: uCase,
+		log:   log,
+	}
+}
+
+// transitionFunc moves order with given ID to another status.
+type transitionFunc func(ctx context.Context, log logrus.FieldLogger, ID uint64) (order.Order, error)
+
+// Process responsible for marking order as processed.
+func (h Handler) Process() http.Handler {
+	return h.change("process", h.uCase.Process)
+}
+
+// Cancel responsible for marking order as canceled.
+func (h Handler) Cancel() http.Handler {
+	return h.change("cancel", h.uCase.Cancel)
+}
+
+func (h Handler) change(action string, transition transitionFunc) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx, h.log)
+
+		ID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil || ID == 0 {
+			log.WithField("id", mux.Vars(r)["id"]).WithError(ErrInvalidOrderID).Error("bad req")
+			httperr.Write(ctx, w, apperr.NewValidation("bad request", ErrInvalidOrderID))
+			return
+		}
+
+		ord, err := transition(ctx, log, ID)
+		if err != nil {
+			log.WithField("order_id", ID).WithError(err).Errorf("can't %s order", action)
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(dto.NewOrderOut(ord))
+	}
+	return http.HandlerFunc(fn)
+}
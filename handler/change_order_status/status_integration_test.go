@@ -0,0 +1,104 @@
+package order_handler_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	change_order_status_handler "github.com/ansakharov/lets_test/handler/change_order_status"
+	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	fake_item "github.com/ansakharov/lets_test/internal/pkg/repository/item/fake_item_repo"
+	fake_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/fake_order_repo"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeOrderStatus(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	repo := fake_order.New()
+	repo.Now = func() time.Time { return time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC) }
+	err := repo.Save(ctx, log, &order.Order{
+		Status:      order.CreatedStatus,
+		UserID:      1,
+		PaymentType: order.Card,
+		Items: []order.Item{
+			{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := change_order_status_handler.New(uCase, log)
+
+	r := mux.NewRouter()
+	r.Handle("/order/{id}/process", h.Process()).Methods("POST")
+	r.Handle("/order/{id}/cancel", h.Cancel()).Methods("POST")
+
+	cases := []struct {
+		name    string
+		url     string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "process",
+			url:     "/order/1/process",
+			expCode: http.StatusOK,
+			expBody: `{"id":1,"status":"processed","user_id":1,"payment_type":"card","original_amount":100,"discounted_amount":10,"created_at":"2022-05-01T12:00:00Z","items":[{"id":2,"quantity":1,"amount":100,"discounted_amount":10}]}` + "\n",
+		},
+		{
+			name:    "process_again",
+			url:     "/order/1/process",
+			expCode: http.StatusConflict,
+			expBody: `{"code":"conflict","message":"invalid status transition: processed -> processed"}` + "\n",
+		},
+		{
+			name:    "cancel",
+			url:     "/order/1/cancel",
+			expCode: http.StatusOK,
+			expBody: `{"id":1,"status":"canceled","user_id":1,"payment_type":"card","original_amount":100,"discounted_amount":10,"created_at":"2022-05-01T12:00:00Z","items":[{"id":2,"quantity":1,"amount":100,"discounted_amount":10}]}` + "\n",
+		},
+		{
+			name:    "process_canceled",
+			url:     "/order/1/process",
+			expCode: http.StatusConflict,
+			expBody: `{"code":"conflict","message":"invalid status transition: canceled -> processed"}` + "\n",
+		},
+		{
+			name:    "not_found",
+			url:     "/order/2/cancel",
+			expCode: http.StatusNotFound,
+			expBody: `{"code":"not_found","message":"order not found"}` + "\n",
+		},
+		{
+			name:    "bad_id",
+			url:     "/order/0/cancel",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid order ID"]}` + "\n",
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tCase.url, nil)
+
+			r.ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody, string(data))
+		})
+	}
+}
@@ -4,33 +4,53 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/ansakharov/lets_test/handler/dto"
+	"github.com/ansakharov/lets_test/handler/httperr"
+	idempotency_ucase "github.com/ansakharov/lets_test/internal/app/usecase/idempotency"
 	create_order "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/logger"
 	"github.com/sirupsen/logrus"
 )
 
 // Requst validation errors.
 var ErrInvalidUserID = errors.New("invalid user ID")
-var ErrInvalidAmount = errors.New("invalid price")
 var ErrInvalidPaymentType = errors.New("invalid payment type")
 var ErrEmptyItems = errors.New("items can't be empty")
 var ErrInvalidItemID = errors.New("invalid service id")
+var ErrInvalidPromoCode = errors.New("invalid promo code")
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+const (
+	// maxPromoCodeLen limits length of promo code passed by client.
+	maxPromoCodeLen = 64
+	// maxQuantity limits number of units in single order line.
+	maxQuantity = 1000
+)
 
 // Handler creates orders
 type Handler struct {
 	uCase *create_order.Usecase
+	keys  *idempotency_ucase.Usecase
 	log   logrus.FieldLogger
 }
 
 // New gives Handler.
+// keys may be nil, then Idempotency-Key header is ignored.
 func New(
 	uCase *create_order.Usecase,
+	keys *idempotency_ucase.Usecase,
 	log logrus.FieldLogger,
 ) *Handler {
 	return &Handler{
 		uCase: uCase,
+		keys:  keys,
 		log:   log,
 	}
 }
@@ -40,12 +60,14 @@ type OrderIn struct {
 	UserID      uint64 `json:"user_id"` // 0
 	PaymentType string `json:"payment_type"`
 	Items       []Item `json:"items"`
+	PromoCode   string `json:"promo_code,omitempty"`
 }
 
+// Item is ordered catalog item.
+// Amounts are calculated by server, so client passes only ID.
 type Item struct {
 	ID       uint64 `json:"id"`
-	Amount   uint64 `json:"amount"`
-	Discount uint64 `json:"discount"`
+	Quantity uint64 `json:"quantity"`
 }
 
 // OrderFromDTO creates Order for business layer.
@@ -53,15 +75,15 @@ func (in OrderIn) OrderFromDTO() order.Order {
 	items := []order.Item{}
 	for _, item := range in.Items {
 		items = append(items, order.Item{
-			ID:               item.ID,
-			Amount:           item.Amount,
-			DiscountedAmount: item.Discount,
+			ID:       item.ID,
+			Quantity: item.Quantity,
 		})
 	}
 	return order.Order{
 		Status:      order.CreatedStatus,
 		UserID:      in.UserID,
 		PaymentType: order.PaymentType(paymentTypes[in.PaymentType]),
+		PromoCode:   in.PromoCode,
 		Items:       items,
 	}
 }
@@ -79,67 +101,97 @@ const (
 	Wallet
 )
 
-// validates request.
+// validates request, all failures are listed in returned error.
 func (h Handler) validateReq(in *OrderIn) error {
+	return in.Validate()
+}
+
+// Validate checks order, all failures are listed in returned error.
+func (in *OrderIn) Validate() error {
+	var errs []error
 	// user ID can't be 0
 	if in.UserID == 0 {
-		return ErrInvalidUserID
+		errs = append(errs, ErrInvalidUserID)
 	}
 	// payment type must be in paymentTypes
 	if _, ok := paymentTypes[in.PaymentType]; !ok {
-		return ErrInvalidPaymentType
+		errs = append(errs, ErrInvalidPaymentType)
 	}
 	// no services passed in request
 	if len(in.Items) == 0 {
-		return ErrEmptyItems
+		errs = append(errs, ErrEmptyItems)
 	}
 	// service doesn't contain valid id
 	for i := range in.Items {
 		if in.Items[i].ID == 0 {
-			return ErrInvalidItemID
+			errs = append(errs, fmt.Errorf("%w: items[%d]", ErrInvalidItemID, i))
 		}
-		if in.Items[i].Amount == 0 {
-			return ErrInvalidAmount
+		if in.Items[i].Quantity == 0 || in.Items[i].Quantity > maxQuantity {
+			errs = append(errs, fmt.Errorf("%w: items[%d]", ErrInvalidQuantity, i))
 		}
 	}
-	return nil
+	if len(in.PromoCode) > maxPromoCodeLen {
+		errs = append(errs, ErrInvalidPromoCode)
+	}
+	return apperr.NewValidation("bad request", errs...)
+}
+
+// orderLocation gives URL of created order.
+func orderLocation(ID uint64) string {
+	return "/order/" + strconv.FormatUint(ID, 10)
 }
 
 // Create responsible for saving new order.
-func (h Handler) Create(ctx context.Context) http.Handler {
+// Requests with Idempotency-Key header are deduplicated when keys are set.
+func (h Handler) Create() http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		// prepare dto to parse request
-		in := &OrderIn{}
-		// parse req body to dto
-		err := json.NewDecoder(r.Body).Decode(&in)
-		if err != nil {
-			h.log.Errorf("can't parse req: %s", err.Error())
-			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
-			return
-		}
+		ctx := r.Context()
 
-		// check that request valid
-		err = h.validateReq(in)
-		if err != nil {
-			h.log.Errorf("bad req: %v: %s", in, err.Error())
-			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		order := in.OrderFromDTO()
-		err = h.uCase.Save(ctx, h.log, &order)
-		if err != nil {
-			h.log.Errorf("can't create order: %v: %s", order, err.Error())
-			http.Error(w, "can't create order: "+err.Error(), http.StatusInternalServerError)
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || h.keys == nil {
+			h.create(ctx, w, r.Body)
 			return
 		}
+		h.createIdempotent(ctx, w, r, key)
+	}
+	return http.HandlerFunc(fn)
+}
 
-		w.Header().Set("Content-Type", "application/json")
+// create saves order from request body and returns its ID.
+func (h Handler) create(ctx context.Context, w http.ResponseWriter, body io.Reader) uint64 {
+	log := logger.FromContext(ctx, h.log)
+
+	// prepare dto to parse request
+	in := &OrderIn{}
+	// parse req body to dto
+	err := json.NewDecoder(body).Decode(&in)
+	if err != nil {
+		log.WithError(err).Error("can't parse req")
+		httperr.Write(ctx, w, apperr.Wrap(apperr.Validation, "bad json", err))
+		return 0
+	}
 
-		m := make(map[string]interface{})
-		m["success"] = "ok"
-		json.NewEncoder(w).Encode(m)
+	// check that request valid
+	err = h.validateReq(in)
+	if err != nil {
+		log.WithError(err).Error("bad req")
+		httperr.Write(ctx, w, err)
+		return 0
+	}
 
+	log = log.WithField("user_id", in.UserID)
+	order := in.OrderFromDTO()
+	err = h.uCase.Save(ctx, log, &order)
+	if err != nil {
+		log.WithError(err).Error("can't create order")
+		httperr.Write(ctx, w, err)
+		return 0
 	}
-	return http.HandlerFunc(fn)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", orderLocation(order.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.NewOrderOut(order))
+
+	return order.ID
 }
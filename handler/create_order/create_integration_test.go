@@ -8,21 +8,49 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	create_order_handler "github.com/ansakharov/lets_test/handler/create_order"
 	get_orders_handler "github.com/ansakharov/lets_test/handler/get_orders"
 	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	promo_ucase "github.com/ansakharov/lets_test/internal/app/usecase/promo"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/promo"
+	fake_item "github.com/ansakharov/lets_test/internal/pkg/repository/item/fake_item_repo"
 	fake_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/fake_order_repo"
 	mock_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/mocks"
+	fake_promo "github.com/ansakharov/lets_test/internal/pkg/repository/promo/fake_promo_repo"
 	"github.com/ansakharov/lets_test/logger"
 	"github.com/ansakharov/lets_test/metrics"
 	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
+// createdAt is creation time of orders saved in tests.
+var createdAt = time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newOrderRepo gives empty orders repository.
+func newOrderRepo() *fake_order.Repository {
+	repo := fake_order.New()
+	repo.Now = func() time.Time { return createdAt }
+	return repo
+}
+
+// newItemRepo gives catalog with premium and calltracking items.
+func newItemRepo(t *testing.T) *fake_item.Repository {
+	repo := fake_item.New()
+	for _, it := range []item.Item{
+		{Name: "premium", Price: 100000},
+		{Name: "calltracking", Price: 20000},
+	} {
+		require.NoError(t, repo.Save(context.Background(), logger.New(), &it))
+	}
+	return repo
+}
+
 func TestCreateOrders(t *testing.T) {
-	metrics.Init()
 	log := logger.New()
 	ctx := context.Background()
 
@@ -36,16 +64,22 @@ func TestCreateOrders(t *testing.T) {
 		UserID:      1,
 		PaymentType: 1,
 		Items: []order.Item{
-			{ID: 2, Amount: 10000, DiscountedAmount: 100},
-			{ID: 2, Amount: 2, DiscountedAmount: 3},
+			{ID: 2, Amount: 20000, Quantity: 1},
+			{ID: 2, Amount: 20000, Quantity: 1},
 		},
 	}
-	repo.EXPECT().Save(ctx, log, &toSave).Return(nil).Times(1)
+	repo.EXPECT().Save(ctx, gomock.Any(), &toSave).DoAndReturn(
+		func(ctx context.Context, log logrus.FieldLogger, ord *order.Order) error {
+			ord.ID = 1
+			ord.CreatedAt = createdAt
+			return nil
+		},
+	).Times(1)
 
-	uCase := order_ucase.New(repo)
-	h := create_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := create_order_handler.New(uCase, nil, log)
 
-	serverFunc := h.Create(ctx).ServeHTTP
+	serverFunc := h.Create().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
@@ -60,11 +94,13 @@ func TestCreateOrders(t *testing.T) {
 				"items": [
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 10000,
 						"discount": 100
 					},
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 2,
 						"discount": 3
 					}
@@ -85,23 +121,25 @@ func TestCreateOrders(t *testing.T) {
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
 
-	expected := "{\"success\":\"ok\"}\n"
+	expected := `{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":40000,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":2,"quantity":1,"amount":20000,"discounted_amount":0},{"id":2,"quantity":1,"amount":20000,"discounted_amount":0}]}
+`
 
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	require.Equal(t, "/order/1", res.Header.Get("Location"))
 	require.Equal(t, expected, string(data))
 }
 
 func TestCreateOrderBadJSON(t *testing.T) {
 	log := logger.New()
-	ctx := context.Background()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 
 	repo := mock_order.NewMockOrderRepo(ctl)
-	uCase := order_ucase.New(repo)
-	h := create_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := create_order_handler.New(uCase, nil, log)
 
-	serverFunc := h.Create(ctx).ServeHTTP
+	serverFunc := h.Create().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
@@ -115,11 +153,13 @@ func TestCreateOrderBadJSON(t *testing.T) {
 				"items": [
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 10000,
 						"discount": 100
 					},
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 2,
 						"discount": 3
 					}
@@ -136,22 +176,20 @@ func TestCreateOrderBadJSON(t *testing.T) {
 
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
-	require.Equal(t, "bad json: unexpected EOF\n", string(data))
+	require.Equal(t, `{"code":"validation","message":"bad json","details":["unexpected EOF"]}`+"\n", string(data))
 }
 
 func TestCreateOrderBadReq(t *testing.T) {
-	metrics.Init()
 	log := logger.New()
-	ctx := context.Background()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 
 	repo := mock_order.NewMockOrderRepo(ctl)
-	uCase := order_ucase.New(repo)
-	h := create_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := create_order_handler.New(uCase, nil, log)
 
-	serverFunc := h.Create(ctx).ServeHTTP
+	serverFunc := h.Create().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
@@ -165,11 +203,13 @@ func TestCreateOrderBadReq(t *testing.T) {
 				"items": [
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 10000,
 						"discount": 100
 					},
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 2,
 						"discount": 3
 					}
@@ -187,11 +227,10 @@ func TestCreateOrderBadReq(t *testing.T) {
 
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
-	require.Equal(t, "bad request: invalid user ID\n", string(data))
+	require.Equal(t, `{"code":"validation","message":"bad request","details":["invalid user ID"]}`+"\n", string(data))
 }
 
 func TestCreateOrderUcaseError(t *testing.T) {
-	metrics.Init()
 	log := logger.New()
 	ctx := context.Background()
 
@@ -206,16 +245,16 @@ func TestCreateOrderUcaseError(t *testing.T) {
 		UserID:      1,
 		PaymentType: 1,
 		Items: []order.Item{
-			{ID: 2, Amount: 10000, DiscountedAmount: 100},
-			{ID: 2, Amount: 2, DiscountedAmount: 3},
+			{ID: 2, Amount: 20000, Quantity: 1},
+			{ID: 2, Amount: 20000, Quantity: 1},
 		},
 	}
-	repo.EXPECT().Save(ctx, log, &toSave).Return(repoErr).Times(1)
+	repo.EXPECT().Save(ctx, gomock.Any(), &toSave).Return(repoErr).Times(1)
 
-	uCase := order_ucase.New(repo)
-	h := create_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := create_order_handler.New(uCase, nil, log)
 
-	serverFunc := h.Create(ctx).ServeHTTP
+	serverFunc := h.Create().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
@@ -230,11 +269,13 @@ func TestCreateOrderUcaseError(t *testing.T) {
 				"items": [
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 10000,
 						"discount": 100
 					},
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 2,
 						"discount": 3
 					}
@@ -255,33 +296,68 @@ func TestCreateOrderUcaseError(t *testing.T) {
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
 
-	expected := "can't create order: can't get orders DB is down\n"
+	expected := `{"code":"internal","message":"internal server error"}` + "\n"
 
 	require.Equal(t, expected, string(data))
 }
 
-func TestCreateAndGetOrder(t *testing.T) {
-	metrics.Init()
+func TestCreateOrderUnknownItems(t *testing.T) {
 	log := logger.New()
-	ctx := context.Background()
 
-	repo := fake_order.New()
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
 
-	uCase := order_ucase.New(repo)
-	hSave := create_order_handler.New(uCase, log)
-	hGet := get_orders_handler.New(uCase, log)
+	repo := mock_order.NewMockOrderRepo(ctl)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := create_order_handler.New(uCase, nil, log)
 
-	getFunc := hGet.Get(ctx).ServeHTTP
+	serverFunc := h.Create().ServeHTTP
 
-	// no orders
-	recGet := httptest.NewRecorder()
-	reqGet := httptest.NewRequest(
+	rec := httptest.NewRecorder()
+
+	req := httptest.NewRequest(
 		http.MethodPost,
-		"/orders",
+		"/order",
 		bytes.NewBuffer([]byte(
-			[]byte(`{"ids": [1]}`),
+			[]byte(`
+			{
+				"user_id": 1,
+				"payment_type": "card",
+				"items": [
+					{"id": 1, "quantity": 1},
+					{"id": 99, "quantity": 1}
+				]
+			}
+			`),
 		)),
 	)
+
+	serverFunc(rec, req)
+
+	res := rec.Result()
+
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	require.Equal(t, `{"code":"unprocessable","message":"unknown items: [99]"}`+"\n", string(data))
+}
+
+func TestCreateAndGetOrder(t *testing.T) {
+	log := logger.New()
+
+	repo := newOrderRepo()
+
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	hSave := create_order_handler.New(uCase, nil, log)
+	hGet := get_orders_handler.New(uCase, get_orders_handler.PageSize{Default: 10, Max: 100}, log)
+
+	getFunc := hGet.Get().ServeHTTP
+
+	// no orders
+	recGet := httptest.NewRecorder()
+	reqGet := httptest.NewRequest(http.MethodGet, "/orders?ids=1", nil)
 	getFunc(recGet, reqGet)
 	res := recGet.Result()
 	defer res.Body.Close()
@@ -289,11 +365,11 @@ func TestCreateAndGetOrder(t *testing.T) {
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
 
-	expected := "[]\n"
+	expected := `{"orders":[],"not_found":[1]}` + "\n"
 	require.Equal(t, expected, string(data))
 
 	// save one
-	saveFunc := hSave.Create(ctx).ServeHTTP
+	saveFunc := hSave.Create().ServeHTTP
 	saveRec := httptest.NewRecorder()
 	saveReq := httptest.NewRequest(
 		http.MethodPost,
@@ -306,11 +382,13 @@ func TestCreateAndGetOrder(t *testing.T) {
 				"items": [
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 10000,
 						"discount": 100
 					},
 					{
 						"id": 2,
+						"quantity": 1,
 						"amount": 2,
 						"discount": 3
 					}
@@ -326,18 +404,14 @@ func TestCreateAndGetOrder(t *testing.T) {
 	saveData, err := ioutil.ReadAll(saveRes.Body)
 	require.NoError(t, err)
 
-	expected = "{\"success\":\"ok\"}\n"
+	expected = `{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":40000,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":2,"quantity":1,"amount":20000,"discounted_amount":0},{"id":2,"quantity":1,"amount":20000,"discounted_amount":0}]}
+`
+	require.Equal(t, http.StatusCreated, saveRes.StatusCode)
 	require.Equal(t, expected, string(saveData))
 
 	// now there is order in db
 	recGet = httptest.NewRecorder()
-	reqGet = httptest.NewRequest(
-		http.MethodPost,
-		"/orders",
-		bytes.NewBuffer([]byte(
-			[]byte(`{"ids": [1]}`),
-		)),
-	)
+	reqGet = httptest.NewRequest(http.MethodGet, "/orders?ids=1", nil)
 	getFunc(recGet, reqGet)
 	res = recGet.Result()
 	defer res.Body.Close()
@@ -345,7 +419,80 @@ func TestCreateAndGetOrder(t *testing.T) {
 	data, err = ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
 
-	expected = `[{"ID":1,"Status":1,"UserID":1,"PaymentType":1,"OriginalAmount":10002,"DiscountedAmount":103,"Items":[{"OrderID":1,"ID":2,"Amount":10000,"DiscountedAmount":100},{"OrderID":1,"ID":2,"Amount":2,"DiscountedAmount":3}]}]
+	expected = `{"orders":[{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":40000,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":2,"quantity":1,"amount":20000,"discounted_amount":0},{"id":2,"quantity":1,"amount":20000,"discounted_amount":0}]}],"not_found":[]}
+`
+	require.Equal(t, expected, string(data))
+}
+
+func TestCreateAndGetOrderWithPromoCode(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	promoRepo := fake_promo.New()
+	require.NoError(t, promoRepo.Save(ctx, log, &promo.Code{
+		Code:    "CALLS10",
+		Kind:    promo.Percent,
+		Value:   10,
+		ItemIDs: []uint64{2},
+	}))
+
+	uCase := order_ucase.New(newOrderRepo(), newItemRepo(t), promo_ucase.New(promoRepo), metrics.Nop{})
+	saveFunc := create_order_handler.New(uCase, nil, log).Create().ServeHTTP
+	getFunc := get_orders_handler.New(uCase, get_orders_handler.PageSize{Default: 10, Max: 100}, log).Get().ServeHTTP
+
+	cases := []struct {
+		name    string
+		body    string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "unknown_code",
+			body:    `{"user_id": 1, "payment_type": "card", "items": [{"id": 2, "quantity": 1}], "promo_code": "NOPE"}`,
+			expCode: http.StatusUnprocessableEntity,
+			expBody: `{"code":"unprocessable","message":"can't apply discounts: discount rejected: promo code not found"}` + "\n",
+		},
+		{
+			name:    "not_applicable",
+			body:    `{"user_id": 1, "payment_type": "card", "items": [{"id": 1, "quantity": 1}], "promo_code": "CALLS10"}`,
+			expCode: http.StatusUnprocessableEntity,
+			expBody: `{"code":"unprocessable","message":"can't apply discounts: discount rejected: promo code doesn't apply to order items"}` + "\n",
+		},
+		{
+			name:    "applied",
+			body:    `{"user_id": 1, "payment_type": "card", "items": [{"id": 1, "quantity": 1}, {"id": 2, "quantity": 3}], "promo_code": "CALLS10"}`,
+			expCode: http.StatusCreated,
+			expBody: `{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":160000,"discounted_amount":6000,"promo_code":"CALLS10","created_at":"2022-05-01T12:00:00Z","items":[{"id":1,"quantity":1,"amount":100000,"discounted_amount":0},{"id":2,"quantity":3,"amount":20000,"discounted_amount":2000}]}` + "\n",
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewBufferString(tCase.body))
+
+			saveFunc(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody, string(data))
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orders?ids=1", nil)
+	getFunc(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	expected := `{"orders":[{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":160000,"discounted_amount":6000,"promo_code":"CALLS10","created_at":"2022-05-01T12:00:00Z","items":[{"id":1,"quantity":1,"amount":100000,"discounted_amount":0},{"id":2,"quantity":3,"amount":20000,"discounted_amount":2000}]}],"not_found":[]}
 `
 	require.Equal(t, expected, string(data))
 }
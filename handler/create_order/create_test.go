@@ -1,6 +1,7 @@
 package order_handler
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -12,7 +13,7 @@ func TestValidate(t *testing.T) {
 		UserID:      1,
 		PaymentType: "card",
 		Items: []Item{
-			{ID: 1, Amount: 10},
+			{ID: 1, Quantity: 1},
 		},
 	}
 	err := h.validateReq(in)
@@ -52,23 +53,58 @@ func TestValidateError(t *testing.T) {
 			expErr: ErrInvalidItemID,
 		},
 		{
-			name: "bad_item_amount",
+			name: "zero_quantity",
 			in: &OrderIn{
 				UserID:      1,
 				PaymentType: "card",
 				Items: []Item{
-					{ID: 1, Amount: 0},
+					{ID: 1, Quantity: 0},
 				},
 			},
-			expErr: ErrInvalidAmount,
+			expErr: ErrInvalidQuantity,
+		},
+		{
+			name: "huge_quantity",
+			in: &OrderIn{
+				UserID:      1,
+				PaymentType: "card",
+				Items: []Item{
+					{ID: 1, Quantity: 1001},
+				},
+			},
+			expErr: ErrInvalidQuantity,
+		},
+		{
+			name: "long_promo_code",
+			in: &OrderIn{
+				UserID:      1,
+				PaymentType: "card",
+				Items: []Item{
+					{ID: 1, Quantity: 1},
+				},
+				PromoCode: strings.Repeat("A", 65),
+			},
+			expErr: ErrInvalidPromoCode,
 		},
 	}
 	h := Handler{}
 	for _, tCase := range cases {
 		t.Run(tCase.name, func(t *testing.T) {
 			err := h.validateReq(tCase.in)
-			require.Error(t, err)
-			require.EqualError(t, tCase.expErr, err.Error())
+			require.ErrorIs(t, err, tCase.expErr)
 		})
 	}
 }
+
+func TestValidateListsAllErrors(t *testing.T) {
+	h := Handler{}
+	in := &OrderIn{
+		PaymentType: "cash",
+		Items: []Item{
+			{ID: 1, Quantity: 1},
+			{ID: 0, Quantity: 0},
+		},
+	}
+	err := h.validateReq(in)
+	require.EqualError(t, err, "bad request: invalid user ID; invalid payment type; invalid service id: items[1]; invalid quantity: items[1]")
+}
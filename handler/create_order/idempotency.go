@@ -0,0 +1,126 @@
+package order_handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ansakharov/lets_test/handler/httperr"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/idempotency"
+	"github.com/ansakharov/lets_test/logger"
+)
+
+const (
+	// IdempotencyKeyHeader carries client generated key of request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from stored result.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLen limits length of key passed by client.
+	maxIdempotencyKeyLen = 255
+	// keyReleaseTimeout limits storing of result and release of key,
+	// which are done even if request context is already done.
+	keyReleaseTimeout = 5 * time.Second
+)
+
+// ErrLongIdempotencyKey returned when Idempotency-Key header exceeds maxIdempotencyKeyLen.
+var ErrLongIdempotencyKey = errors.New("idempotency key is too long")
+
+// createIdempotent saves order once per idempotency key.
+// Replays with the same body get stored response.
+func (h Handler) createIdempotent(ctx context.Context, w http.ResponseWriter, r *http.Request, key string) {
+	log := logger.FromContext(ctx, h.log)
+
+	if len(key) > maxIdempotencyKeyLen {
+		log.WithError(ErrLongIdempotencyKey).Error("bad req")
+		httperr.Write(ctx, w, apperr.NewValidation("bad request", ErrLongIdempotencyKey))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("can't read req")
+		httperr.Write(ctx, w, apperr.Wrap(apperr.Validation, "can't read request", err))
+		return
+	}
+
+	stored, err := h.keys.Begin(ctx, log, key, body)
+	if err != nil {
+		log.WithField("idempotency_key", key).WithError(err).Error("can't use idempotency key")
+		httperr.Write(ctx, w, err)
+		return
+	}
+
+	// request was already made, replay its result.
+	if stored != nil {
+		if stored.ContentType != "" {
+			w.Header().Set("Content-Type", stored.ContentType)
+		}
+		if stored.OrderID != 0 {
+			w.Header().Set("Location", orderLocation(stored.OrderID))
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(stored.StatusCode)
+		w.Write(stored.Body)
+		return
+	}
+
+	// key must not stay in progress when result isn't stored:
+	// server error, panic of handler or failure of storing itself.
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		// released even if request timed out or client is gone.
+		ctx, cancel := context.WithTimeout(context.Background(), keyReleaseTimeout)
+		defer cancel()
+		if err := h.keys.Abort(ctx, log, key, body); err != nil {
+			log.WithField("idempotency_key", key).WithError(err).Error("can't release idempotency key")
+		}
+	}()
+
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	orderID := h.create(ctx, rec, bytes.NewReader(body))
+
+	// server errors are not stored, so client can retry request.
+	if rec.status >= http.StatusInternalServerError {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), keyReleaseTimeout)
+	defer cancel()
+	err = h.keys.Finish(ctx, log, body, idempotency.Record{
+		Key:         key,
+		StatusCode:  rec.status,
+		ContentType: rec.Header().Get("Content-Type"),
+		Body:        rec.body.Bytes(),
+		OrderID:     orderID,
+	})
+	if err != nil {
+		log.WithField("idempotency_key", key).WithError(err).Error("can't store result for idempotency key")
+		return
+	}
+	finished = true
+}
+
+// responseRecorder writes response and keeps its copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
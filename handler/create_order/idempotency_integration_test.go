@@ -0,0 +1,183 @@
+package order_handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	create_order_handler "github.com/ansakharov/lets_test/handler/create_order"
+	idempotency_ucase "github.com/ansakharov/lets_test/internal/app/usecase/idempotency"
+	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	fake_idempotency "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency/fake_idempotency_repo"
+	mock_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/mocks"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderIdempotencyKey(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	repo := newOrderRepo()
+	keys := idempotency_ucase.New(fake_idempotency.New(), time.Hour, time.Minute)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	serverFunc := create_order_handler.New(uCase, keys, log).Create().ServeHTTP
+
+	body := `{"user_id": 1, "payment_type": "card", "items": [{"id": 1, "quantity": 1}]}`
+	created := `{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":100000,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[{"id":1,"quantity":1,"amount":100000,"discounted_amount":0}]}` + "\n"
+
+	// key "B" is being used by another request.
+	_, err := keys.Begin(ctx, log, "B", []byte(body))
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		key         string
+		body        string
+		expCode     int
+		expBody     string
+		expReplayed bool
+	}{
+		{
+			name:    "first",
+			key:     "A",
+			body:    body,
+			expCode: http.StatusCreated,
+			expBody: created,
+		},
+		{
+			name:        "replay",
+			key:         "A",
+			body:        body,
+			expCode:     http.StatusCreated,
+			expBody:     created,
+			expReplayed: true,
+		},
+		{
+			name:    "another_body",
+			key:     "A",
+			body:    `{"user_id": 2, "payment_type": "card", "items": [{"id": 1, "quantity": 1}]}`,
+			expCode: http.StatusUnprocessableEntity,
+			expBody: `{"code":"unprocessable","message":"idempotency key was used with another request"}` + "\n",
+		},
+		{
+			name:    "in_progress",
+			key:     "B",
+			body:    body,
+			expCode: http.StatusConflict,
+			expBody: `{"code":"conflict","message":"request with idempotency key is in progress"}` + "\n",
+		},
+		{
+			name:    "bad_request",
+			key:     "C",
+			body:    `{"payment_type": "card"}`,
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid user ID","items can't be empty"]}` + "\n",
+		},
+		{
+			name:        "bad_request_replay",
+			key:         "C",
+			body:        `{"payment_type": "card"}`,
+			expCode:     http.StatusBadRequest,
+			expBody:     `{"code":"validation","message":"bad request","details":["invalid user ID","items can't be empty"]}` + "\n",
+			expReplayed: true,
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewBufferString(tCase.body))
+			req.Header.Set(create_order_handler.IdempotencyKeyHeader, tCase.key)
+
+			serverFunc(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody, string(data))
+			require.Equal(t, tCase.expReplayed, res.Header.Get(create_order_handler.IdempotentReplayedHeader) == "true")
+			if tCase.expCode == http.StatusCreated {
+				require.Equal(t, "/order/1", res.Header.Get("Location"))
+			}
+		})
+	}
+
+	// only one order was created.
+	orders, err := repo.Get(ctx, log, order.Filter{IDs: []uint64{1, 2}})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+}
+
+func TestCreateOrderIdempotencyKeyRetryAfterError(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mock_order.NewMockOrderRepo(ctl)
+	gomock.InOrder(
+		repo.EXPECT().Save(ctx, gomock.Any(), gomock.Any()).Return(errors.New("db is down")).Times(1),
+		repo.EXPECT().Save(ctx, gomock.Any(), gomock.Any()).Return(nil).Times(1),
+	)
+
+	keys := idempotency_ucase.New(fake_idempotency.New(), time.Hour, time.Minute)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	serverFunc := create_order_handler.New(uCase, keys, log).Create().ServeHTTP
+
+	body := `{"user_id": 1, "payment_type": "card", "items": [{"id": 1, "quantity": 1}]}`
+	for _, expCode := range []int{http.StatusInternalServerError, http.StatusCreated} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewBufferString(body))
+		req.Header.Set(create_order_handler.IdempotencyKeyHeader, "A")
+
+		serverFunc(rec, req)
+
+		require.Equal(t, expCode, rec.Code)
+	}
+}
+
+func TestCreateOrderIdempotencyKeyReleasedOnPanic(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mock_order.NewMockOrderRepo(ctl)
+	gomock.InOrder(
+		repo.EXPECT().Save(ctx, gomock.Any(), gomock.Any()).Do(func(_, _, _ interface{}) { panic("boom") }).Times(1),
+		repo.EXPECT().Save(ctx, gomock.Any(), gomock.Any()).Return(nil).Times(1),
+	)
+
+	keys := idempotency_ucase.New(fake_idempotency.New(), time.Hour, time.Minute)
+	uCase := order_ucase.New(repo, newItemRepo(t), order_ucase.NoDiscount{}, metrics.Nop{})
+	serverFunc := create_order_handler.New(uCase, keys, log).Create().ServeHTTP
+
+	body := `{"user_id": 1, "payment_type": "card", "items": [{"id": 1, "quantity": 1}]}`
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewBufferString(body))
+		req.Header.Set(create_order_handler.IdempotencyKeyHeader, "A")
+
+		serverFunc(rec, req)
+		return rec
+	}
+
+	// panic is recovered by middleware in router.
+	require.Panics(t, func() { serve() })
+
+	rec := serve()
+	require.Equal(t, http.StatusCreated, rec.Code)
+}
@@ -0,0 +1,64 @@
+// Package dto holds http representations of entities shared by handlers.
+package dto
+
+import (
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+)
+
+// OrderOut is order in http responses.
+// Field names and enums match OrderIn of create_order handler.
+type OrderOut struct {
+	ID               uint64         `json:"id"`
+	Status           string         `json:"status"`
+	UserID           uint64         `json:"user_id"`
+	PaymentType      string         `json:"payment_type"`
+	OriginalAmount   uint64         `json:"original_amount"`
+	DiscountedAmount uint64         `json:"discounted_amount"`
+	PromoCode        string         `json:"promo_code,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	Items            []OrderItemOut `json:"items"`
+}
+
+// OrderItemOut is order line in http responses.
+type OrderItemOut struct {
+	ID               uint64 `json:"id"`
+	Quantity         uint64 `json:"quantity"`
+	Amount           uint64 `json:"amount"`
+	DiscountedAmount uint64 `json:"discounted_amount"`
+}
+
+// NewOrderOut gives dto of order.
+func NewOrderOut(ord order.Order) OrderOut {
+	items := make([]OrderItemOut, 0, len(ord.Items))
+	for _, item := range ord.Items {
+		items = append(items, OrderItemOut{
+			ID:               item.ID,
+			Quantity:         item.Quantity,
+			Amount:           item.Amount,
+			DiscountedAmount: item.DiscountedAmount,
+		})
+	}
+
+	return OrderOut{
+		ID:               ord.ID,
+		Status:           ord.Status.String(),
+		UserID:           ord.UserID,
+		PaymentType:      ord.PaymentType.String(),
+		OriginalAmount:   ord.OriginalAmount,
+		DiscountedAmount: ord.DiscountedAmount,
+		PromoCode:        ord.PromoCode,
+		CreatedAt:        ord.CreatedAt,
+		Items:            items,
+	}
+}
+
+// NewOrdersOut gives dto of orders, it's never nil.
+func NewOrdersOut(orders []order.Order) []OrderOut {
+	out := make([]OrderOut, 0, len(orders))
+	for _, ord := range orders {
+		out = append(out, NewOrderOut(ord))
+	}
+	return out
+}
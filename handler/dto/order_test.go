@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderOutJSON(t *testing.T) {
+	ord := order.Order{
+		ID:               1,
+		Status:           order.ProcessedStatus,
+		UserID:           2,
+		PaymentType:      order.Wallet,
+		OriginalAmount:   300,
+		DiscountedAmount: 30,
+		PromoCode:        "CALLS10",
+		CreatedAt:        time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC),
+		Items: []order.Item{
+			{OrderID: 1, ID: 3, Amount: 100, DiscountedAmount: 10, Quantity: 3},
+		},
+	}
+
+	out, err := json.Marshal(NewOrderOut(ord))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": 1,
+		"status": "processed",
+		"user_id": 2,
+		"payment_type": "wallet",
+		"original_amount": 300,
+		"discounted_amount": 30,
+		"promo_code": "CALLS10",
+		"created_at": "2022-05-01T12:00:00Z",
+		"items": [{"id": 3, "quantity": 3, "amount": 100, "discounted_amount": 10}]
+	}`, string(out))
+}
+
+func TestNewOrdersOutEmpty(t *testing.T) {
+	out, err := json.Marshal(NewOrdersOut(nil))
+	require.NoError(t, err)
+	require.Equal(t, `[]`, string(out))
+
+	out, err = json.Marshal(NewOrderOut(order.Order{}).Items)
+	require.NoError(t, err)
+	require.Equal(t, `[]`, string(out))
+}
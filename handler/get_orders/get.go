@@ -4,76 +4,301 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ansakharov/lets_test/handler/dto"
+	"github.com/ansakharov/lets_test/handler/httperr"
 	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
 // Requst validation errors.
-var ErrEmptyOrderIDs = errors.New("no order ids passed")
+var ErrEmptyFilter = errors.New("no order ids or filters passed")
+var ErrInvalidOrderID = errors.New("invalid order ID")
+var ErrInvalidStatus = errors.New("invalid status")
+var ErrInvalidPaymentType = errors.New("invalid payment type")
+var ErrInvalidPeriod = errors.New("created_from must be before created_to")
+var ErrInvalidLimit = errors.New("limit exceeds max page size")
+var ErrTooManyIDs = errors.New("too many order ids")
+var ErrPageWithIDs = errors.New("cursor and limit can't be used with ids")
+var ErrStrictWithoutIDs = errors.New("strict can be used only with ids")
 
-// Handler creates orders
+// ErrOrdersNotFound returned in strict mode when some of requested orders are missing.
+var ErrOrdersNotFound = apperr.New(apperr.NotFound, "orders not found")
+
+// PageSize limits number of orders listed in one response.
+type PageSize struct {
+	// Default is used when client doesn't pass limit.
+	Default uint64
+	// Max is the biggest limit and number of ids client can ask for.
+	Max uint64
+}
+
+// Handler gives orders
 type Handler struct {
-	uCase *order_ucase.Usecase
-	log   logrus.FieldLogger
+	uCase    *order_ucase.Usecase
+	pageSize PageSize
+	log      logrus.FieldLogger
 }
 
 // New gives Handler.
 func New(
 	uCase *order_ucase.Usecase,
+	pageSize PageSize,
 	log logrus.FieldLogger,
 ) *Handler {
 	return &Handler{
-		uCase: uCase,
-		log:   log,
+		uCase:    uCase,
+		pageSize: pageSize,
+		log:      log,
 	}
 }
 
 // GetOrdersIn is dto for http req.
 type GetOrdersIn struct {
-	IDs []uint64 `json:"ids"`
+	IDs         []uint64
+	UserID      uint64
+	Status      string
+	PaymentType string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Cursor and Limit are used only for listing without ids.
+	Cursor *order.Cursor
+	Limit  uint64
+	// Strict makes request fail if any of ids is missing.
+	Strict bool
+}
+
+// GetOrdersOut is dto for http resp.
+type GetOrdersOut struct {
+	Orders []dto.OrderOut `json:"orders"`
+	// NotFound lists requested ids without orders, it's [] if all orders found.
+	// It's set only for lookup by ids and omitted in listing.
+	NotFound *[]uint64 `json:"not_found,omitempty"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// parseQuery fills dto from query string like ?ids=1,2,3&user_id=1.
+// All malformed params are listed in returned error.
+func parseQuery(query url.Values) (*GetOrdersIn, error) {
+	var errs []error
+	in := &GetOrdersIn{
+		Status:      query.Get("status"),
+		PaymentType: query.Get("payment_type"),
+	}
+
+	// ids can be passed as ids=1,2 or ids=1&ids=2.
+	for _, value := range query["ids"] {
+		for _, rawID := range strings.Split(value, ",") {
+			ID, err := strconv.ParseUint(strings.TrimSpace(rawID), 10, 64)
+			if err != nil || ID == 0 {
+				errs = append(errs, fmt.Errorf("invalid id %q", rawID))
+				continue
+			}
+			in.IDs = append(in.IDs, ID)
+		}
+	}
+
+	var err error
+	if value := query.Get("user_id"); value != "" {
+		in.UserID, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid user_id %q", value))
+		}
+	}
+	if value := query.Get("created_from"); value != "" {
+		in.CreatedFrom, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid created_from %q, RFC3339 expected", value))
+		}
+	}
+	if value := query.Get("created_to"); value != "" {
+		in.CreatedTo, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid created_to %q, RFC3339 expected", value))
+		}
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := order.DecodeCursor(value)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			in.Cursor = &cursor
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		in.Limit, err = strconv.ParseUint(value, 10, 64)
+		if err != nil || in.Limit == 0 {
+			errs = append(errs, fmt.Errorf("invalid limit %q", value))
+		}
+	}
+	if value := query.Get("strict"); value != "" {
+		in.Strict, err = strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid strict %q", value))
+		}
+	}
+
+	if err := apperr.NewValidation("bad query", errs...); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// FilterFromDTO creates Filter for business layer.
+func (in GetOrdersIn) FilterFromDTO() order.Filter {
+	status, _ := order.ParseStatus(in.Status)
+	paymentType, _ := order.ParsePaymentType(in.PaymentType)
+
+	return order.Filter{
+		IDs:         in.IDs,
+		UserID:      in.UserID,
+		Status:      status,
+		PaymentType: paymentType,
+		CreatedFrom: in.CreatedFrom,
+		CreatedTo:   in.CreatedTo,
+	}
 }
 
-// validates request.
+// validates request, all failures are listed in returned error.
 func (h Handler) validateReq(in *GetOrdersIn) error {
-	if len(in.IDs) == 0 {
-		return ErrEmptyOrderIDs
+	var errs []error
+	if len(in.IDs) == 0 &&
+		in.UserID == 0 &&
+		in.Status == "" &&
+		in.PaymentType == "" &&
+		in.CreatedFrom.IsZero() &&
+		in.CreatedTo.IsZero() {
+		errs = append(errs, ErrEmptyFilter)
+	}
+	if _, ok := order.ParseStatus(in.Status); in.Status != "" && !ok {
+		errs = append(errs, ErrInvalidStatus)
+	}
+	if _, ok := order.ParsePaymentType(in.PaymentType); in.PaymentType != "" && !ok {
+		errs = append(errs, ErrInvalidPaymentType)
+	}
+	if !in.CreatedFrom.IsZero() && !in.CreatedTo.IsZero() && !in.CreatedFrom.Before(in.CreatedTo) {
+		errs = append(errs, ErrInvalidPeriod)
+	}
+	if len(in.IDs) > 0 && (in.Cursor != nil || in.Limit != 0) {
+		errs = append(errs, ErrPageWithIDs)
+	}
+	if len(in.IDs) == 0 && in.Strict {
+		errs = append(errs, ErrStrictWithoutIDs)
+	}
+	if uint64(len(in.IDs)) > h.pageSize.Max {
+		errs = append(errs, fmt.Errorf("%w: max %d", ErrTooManyIDs, h.pageSize.Max))
+	}
+	if in.Limit > h.pageSize.Max {
+		errs = append(errs, fmt.Errorf("%w: max %d", ErrInvalidLimit, h.pageSize.Max))
 	}
 
-	return nil
+	return apperr.NewValidation("bad request", errs...)
 }
 
-// Create responsible for saving new order.
-func (h Handler) Get(ctx context.Context) http.Handler {
+// Get responsible for giving orders matching query.
+// Orders requested by ids are given at once in order of ids, others are listed page by page.
+func (h Handler) Get() http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		// prepare dto to parse request
-		in := &GetOrdersIn{}
-		// parse req body to dto
-		err := json.NewDecoder(r.Body).Decode(&in)
+		ctx := r.Context()
+		log := logger.FromContext(ctx, h.log)
+
+		// parse query string to dto
+		in, err := parseQuery(r.URL.Query())
 		if err != nil {
-			h.log.Errorf("can't parse req: %s", err.Error())
-			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			log.WithError(err).Error("can't parse req")
+			httperr.Write(ctx, w, err)
 			return
 		}
 
 		// check that request valid
 		err = h.validateReq(in)
 		if err != nil {
-			h.log.Errorf("bad req: %v: %s", in, err.Error())
-			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			log.WithError(err).Error("bad req")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		out, err := h.get(ctx, in)
+		if err != nil {
+			log.WithError(err).Error("can't get orders")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// get gives orders by ids or page of orders matching filter.
+func (h Handler) get(ctx context.Context, in *GetOrdersIn) (*GetOrdersOut, error) {
+	log := logger.FromContext(ctx, h.log)
+	if in.UserID != 0 {
+		log = log.WithField("user_id", in.UserID)
+	}
+
+	filter := in.FilterFromDTO()
+	if len(in.IDs) > 0 {
+		orders, notFound, err := h.uCase.Get(ctx, log, filter)
+		if err != nil {
+			return nil, err
+		}
+		if in.Strict && len(notFound) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrOrdersNotFound, notFound)
+		}
+		return &GetOrdersOut{Orders: dto.NewOrdersOut(orders), NotFound: &notFound}, nil
+	}
+
+	limit := in.Limit
+	if limit == 0 {
+		limit = h.pageSize.Default
+	}
+	orders, next, err := h.uCase.List(ctx, log, filter, in.Cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &GetOrdersOut{Orders: dto.NewOrdersOut(orders)}
+	if next != nil {
+		out.NextCursor = next.Encode()
+	}
+	return out, nil
+}
+
+// GetByID responsible for giving single order.
+func (h Handler) GetByID() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx, h.log)
+
+		ID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil || ID == 0 {
+			log.WithField("id", mux.Vars(r)["id"]).WithError(ErrInvalidOrderID).Error("bad req")
+			httperr.Write(ctx, w, apperr.NewValidation("bad request", ErrInvalidOrderID))
 			return
 		}
 
-		orders, err := h.uCase.Get(ctx, h.log, in.IDs)
+		ord, err := h.uCase.GetByID(ctx, log, ID)
 		if err != nil {
-			h.log.Errorf("can't get orders: %s", err.Error())
-			http.Error(w, "can't get orders: "+err.Error(), http.StatusInternalServerError)
+			log.WithField("order_id", ID).WithError(err).Error("can't get order")
+			httperr.Write(ctx, w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(orders)
+		json.NewEncoder(w).Encode(dto.NewOrderOut(ord))
 	}
 	return http.HandlerFunc(fn)
 }
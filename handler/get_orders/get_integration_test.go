@@ -1,27 +1,32 @@
 package order_handler_test
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	get_order_handler "github.com/ansakharov/lets_test/handler/get_orders"
+	"github.com/ansakharov/lets_test/handler/middleware"
 	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	fake_item "github.com/ansakharov/lets_test/internal/pkg/repository/item/fake_item_repo"
+	fake_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/fake_order_repo"
 	mock_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/mocks"
 	"github.com/ansakharov/lets_test/logger"
 	"github.com/ansakharov/lets_test/metrics"
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
 func TestGetOrders(t *testing.T) {
-	metrics.Init()
 	log := logger.New()
 	ctx := context.Background()
 
@@ -47,29 +52,25 @@ func TestGetOrders(t *testing.T) {
 			Items: []order.Item{
 				{
 					OrderID: uint64(reqID),
-					ID:      1, Amount: 100, DiscountedAmount: 0,
+					ID:      1, Amount: 100, DiscountedAmount: 0, Quantity: 1,
 				},
 			},
 		},
 	}
-	repo.EXPECT().Get(ctx, log, []uint64{uint64(reqID)}).Return(exp, nil).Times(1)
+	repo.EXPECT().Get(ctx, log, order.Filter{IDs: []uint64{uint64(reqID)}}).Return(exp, nil).Times(1)
 
-	uCase := order_ucase.New(repo)
-	h := get_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log)
 
-	serverFunc := h.Get(ctx).ServeHTTP
+	serverFunc := h.Get().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
 	req := httptest.NewRequest(
-		http.MethodPost,
-		"/orders",
-		bytes.NewBuffer([]byte(
-			[]byte(fmt.Sprintf(`{"ids": [%d]}`, reqID)),
-		)),
+		http.MethodGet,
+		fmt.Sprintf("/orders?ids=%d", reqID),
+		nil,
 	)
-	// if you need it
-	req.Header.Set("Content-Type", "Application/Json")
 
 	serverFunc(rec, req)
 
@@ -81,37 +82,30 @@ func TestGetOrders(t *testing.T) {
 	require.NoError(t, err)
 
 	expected :=
-		`[{"ID":1,"Status":0,"UserID":1,"PaymentType":1,"OriginalAmount":100,"DiscountedAmount":0,"Items":[{"OrderID":1,"ID":1,"Amount":100,"DiscountedAmount":0}]}]` +
+		`{"orders":[{"id":1,"status":"unknown","user_id":1,"payment_type":"card","original_amount":100,"discounted_amount":0,"created_at":"0001-01-01T00:00:00Z","items":[{"id":1,"quantity":1,"amount":100,"discounted_amount":0}]}],"not_found":[]}` +
 			"\n"
 
 	require.Equal(t, expected, string(data))
 }
 
-func TestGetOrdersBadJSON(t *testing.T) {
-	// {"ids": [999]
-	// {"ids": asdfoij}
-	// {: asdfoij}
-	metrics.Init()
+func TestGetOrdersBadQuery(t *testing.T) {
 	log := logger.New()
-	ctx := context.Background()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 
 	repo := mock_order.NewMockOrderRepo(ctl)
-	uCase := order_ucase.New(repo)
-	h := get_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log)
 
-	serverFunc := h.Get(ctx).ServeHTTP
+	serverFunc := h.Get().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
 	req := httptest.NewRequest(
-		http.MethodPost,
-		"/orders",
-		bytes.NewBuffer([]byte(
-			[]byte(`{"ids": [999]`),
-		)),
+		http.MethodGet,
+		"/orders?ids=1,abc",
+		nil,
 	)
 
 	serverFunc(rec, req)
@@ -122,31 +116,27 @@ func TestGetOrdersBadJSON(t *testing.T) {
 
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
-	require.Equal(t, "bad json: unexpected EOF\n", string(data))
+	require.Equal(t, `{"code":"validation","message":"bad query","details":["invalid id \"abc\""]}`+"\n", string(data))
 }
 
 func TestGetOrdersBadReq(t *testing.T) {
-	metrics.Init()
 	log := logger.New()
-	ctx := context.Background()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 
 	repo := mock_order.NewMockOrderRepo(ctl)
-	uCase := order_ucase.New(repo)
-	h := get_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log)
 
-	serverFunc := h.Get(ctx).ServeHTTP
+	serverFunc := h.Get().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
 	req := httptest.NewRequest(
-		http.MethodPost,
+		http.MethodGet,
 		"/orders",
-		bytes.NewBuffer([]byte(
-			[]byte(`{"ids": []}`),
-		)),
+		nil,
 	)
 
 	serverFunc(rec, req)
@@ -157,11 +147,10 @@ func TestGetOrdersBadReq(t *testing.T) {
 
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
-	require.Equal(t, "bad request: no order ids passed\n", string(data))
+	require.Equal(t, `{"code":"validation","message":"bad request","details":["no order ids or filters passed"]}`+"\n", string(data))
 }
 
 func TestGetOrdersUcaseError(t *testing.T) {
-	metrics.Init()
 	log := logger.New()
 	ctx := context.Background()
 
@@ -178,24 +167,20 @@ func TestGetOrdersUcaseError(t *testing.T) {
 
 	repoErr := errors.New("can't get orders DB is down")
 
-	repo.EXPECT().Get(ctx, log, []uint64{uint64(reqID)}).Return(nil, repoErr).Times(1)
+	repo.EXPECT().Get(ctx, log, order.Filter{IDs: []uint64{uint64(reqID)}}).Return(nil, repoErr).Times(1)
 
-	uCase := order_ucase.New(repo)
-	h := get_order_handler.New(uCase, log)
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log)
 
-	serverFunc := h.Get(ctx).ServeHTTP
+	serverFunc := h.Get().ServeHTTP
 
 	rec := httptest.NewRecorder()
 
 	req := httptest.NewRequest(
-		http.MethodPost,
-		"/orders",
-		bytes.NewBuffer([]byte(
-			[]byte(fmt.Sprintf(`{"ids": [%d]}`, reqID)),
-		)),
+		http.MethodGet,
+		fmt.Sprintf("/orders?ids=%d", reqID),
+		nil,
 	)
-	// if you need it
-	req.Header.Set("Content-Type", "Application/Json")
 
 	serverFunc(rec, req)
 
@@ -206,7 +191,351 @@ func TestGetOrdersUcaseError(t *testing.T) {
 	data, err := ioutil.ReadAll(res.Body)
 	require.NoError(t, err)
 
-	expected := "can't get orders: err from orders_repository: can't get orders DB is down\n"
+	expected := `{"code":"internal","message":"internal server error"}` + "\n"
 
 	require.Equal(t, expected, string(data))
 }
+
+func TestGetOrdersTimeout(t *testing.T) {
+	log := logger.New()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mock_order.NewMockOrderRepo(ctl)
+
+	// repository is canceled by request deadline.
+	repo.EXPECT().
+		List(gomock.Any(), gomock.Any(), order.Filter{UserID: 1}, nil, uint64(11)).
+		DoAndReturn(func(ctx context.Context, _ logrus.FieldLogger, _ order.Filter, _ *order.Cursor, _ uint64) ([]order.Order, error) {
+			<-ctx.Done()
+			return nil, fmt.Errorf("can't select orders: %w", ctx.Err())
+		}).
+		Times(1)
+
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log)
+
+	r := mux.NewRouter()
+	r.Use(middleware.Timeout(10*time.Millisecond, nil))
+	r.Handle("/orders", h.Get()).Methods("GET")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orders?user_id=1", nil)
+
+	r.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+	require.Equal(t, `{"code":"timeout","message":"request timed out"}`+"\n", string(data))
+}
+
+func TestGetOrdersFilters(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	repo := fake_order.New()
+	day := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	saved := []order.Order{
+		{Status: order.CreatedStatus, UserID: 1, PaymentType: order.Card},
+		{Status: order.ProcessedStatus, UserID: 1, PaymentType: order.Wallet},
+		{Status: order.CreatedStatus, UserID: 2, PaymentType: order.Card},
+	}
+	for idx := range saved {
+		createdAt := day.AddDate(0, 0, idx)
+		repo.Now = func() time.Time { return createdAt }
+		require.NoError(t, repo.Save(ctx, log, &saved[idx]))
+	}
+
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	serverFunc := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log).Get().ServeHTTP
+
+	cases := []struct {
+		name    string
+		url     string
+		expCode int
+		expIDs  []uint64
+		expBody string
+	}{
+		{name: "ids", url: "/orders?ids=1,3", expCode: http.StatusOK, expIDs: []uint64{1, 3}},
+		{name: "ids_order", url: "/orders?ids=3,1,2", expCode: http.StatusOK, expIDs: []uint64{3, 1, 2}},
+		{name: "repeated_ids", url: "/orders?ids=1&ids=2", expCode: http.StatusOK, expIDs: []uint64{1, 2}},
+		{name: "user", url: "/orders?user_id=1", expCode: http.StatusOK, expIDs: []uint64{2, 1}},
+		{name: "status", url: "/orders?status=created", expCode: http.StatusOK, expIDs: []uint64{3, 1}},
+		{name: "payment_type", url: "/orders?payment_type=wallet", expCode: http.StatusOK, expIDs: []uint64{2}},
+		{name: "user_and_status", url: "/orders?user_id=1&status=created", expCode: http.StatusOK, expIDs: []uint64{1}},
+		{
+			name:    "period",
+			url:     "/orders?created_from=2022-05-02T12:00:00Z&created_to=2022-05-03T12:00:00Z",
+			expCode: http.StatusOK,
+			expIDs:  []uint64{2},
+		},
+		{name: "nothing_found", url: "/orders?user_id=3", expCode: http.StatusOK, expIDs: []uint64{}},
+		{
+			name:    "bad_user_id",
+			url:     "/orders?user_id=x",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad query","details":["invalid user_id \"x\""]}` + "\n",
+		},
+		{
+			name:    "bad_status",
+			url:     "/orders?status=lost",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid status"]}` + "\n",
+		},
+		{
+			name:    "bad_payment_type",
+			url:     "/orders?payment_type=cash",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid payment type"]}` + "\n",
+		},
+		{
+			name:    "bad_created_from",
+			url:     "/orders?created_from=yesterday",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad query","details":["invalid created_from \"yesterday\", RFC3339 expected"]}` + "\n",
+		},
+		{
+			name:    "bad_cursor",
+			url:     "/orders?user_id=1&cursor=abc",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad query","details":["invalid cursor"]}` + "\n",
+		},
+		{
+			name:    "bad_limit",
+			url:     "/orders?user_id=1&limit=0",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad query","details":["invalid limit \"0\""]}` + "\n",
+		},
+		{
+			name:    "too_big_limit",
+			url:     "/orders?user_id=1&limit=101",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["limit exceeds max page size: max 100"]}` + "\n",
+		},
+		{
+			name:    "limit_with_ids",
+			url:     "/orders?ids=1&limit=1",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["cursor and limit can't be used with ids"]}` + "\n",
+		},
+		{
+			name:    "bad_period",
+			url:     "/orders?created_from=2022-05-03T12:00:00Z&created_to=2022-05-02T12:00:00Z",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["created_from must be before created_to"]}` + "\n",
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tCase.url, nil)
+
+			serverFunc(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			if tCase.expCode != http.StatusOK {
+				require.Equal(t, tCase.expBody, string(data))
+				return
+			}
+
+			out := get_order_handler.GetOrdersOut{}
+			require.NoError(t, json.Unmarshal(data, &out))
+			require.Empty(t, out.NextCursor)
+			IDs := make([]uint64, 0, len(out.Orders))
+			for _, ord := range out.Orders {
+				IDs = append(IDs, ord.ID)
+			}
+			require.Equal(t, tCase.expIDs, IDs)
+		})
+	}
+}
+
+func TestGetOrdersNotFound(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	repo := fake_order.New()
+	repo.Now = func() time.Time { return time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC) }
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.Save(ctx, log, &order.Order{UserID: 1, PaymentType: order.Card}))
+	}
+
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	serverFunc := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log).Get().ServeHTTP
+
+	cases := []struct {
+		name    string
+		url     string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "all_found",
+			url:     "/orders?ids=2,1&strict=true",
+			expCode: http.StatusOK,
+			expBody: `{"orders":[{"id":2,"status":"unknown","user_id":1,"payment_type":"card","original_amount":0,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[]},{"id":1,"status":"unknown","user_id":1,"payment_type":"card","original_amount":0,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[]}],"not_found":[]}` + "\n",
+		},
+		{
+			name:    "not_found",
+			url:     "/orders?ids=7,3,5",
+			expCode: http.StatusOK,
+			expBody: `{"orders":[{"id":3,"status":"unknown","user_id":1,"payment_type":"card","original_amount":0,"discounted_amount":0,"created_at":"2022-05-01T12:00:00Z","items":[]}],"not_found":[7,5]}` + "\n",
+		},
+		{
+			name:    "strict_not_found",
+			url:     "/orders?ids=7,3,5&strict=true",
+			expCode: http.StatusNotFound,
+			expBody: `{"code":"not_found","message":"orders not found: [7 5]"}` + "\n",
+		},
+		{
+			name:    "strict_without_ids",
+			url:     "/orders?user_id=1&strict=true",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["strict can be used only with ids"]}` + "\n",
+		},
+		{
+			name:    "bad_strict",
+			url:     "/orders?ids=1&strict=yes",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad query","details":["invalid strict \"yes\""]}` + "\n",
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tCase.url, nil)
+
+			serverFunc(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody, string(data))
+		})
+	}
+}
+
+func TestGetOrdersPages(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	repo := fake_order.New()
+	createdAt := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	// orders 2 and 3 are created at the same time, id breaks the tie.
+	for _, offset := range []time.Duration{0, time.Hour, time.Hour, 2 * time.Hour, 3 * time.Hour} {
+		orderCreatedAt := createdAt.Add(offset)
+		repo.Now = func() time.Time { return orderCreatedAt }
+		require.NoError(t, repo.Save(ctx, log, &order.Order{UserID: 1, PaymentType: order.Card}))
+	}
+	require.NoError(t, repo.Save(ctx, log, &order.Order{UserID: 2, PaymentType: order.Card}))
+
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	serverFunc := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 2, Max: 100}, log).Get().ServeHTTP
+
+	pages := [][]uint64{}
+	url := "/orders?user_id=1"
+	for {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+
+		serverFunc(rec, req)
+
+		res := rec.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		out := get_order_handler.GetOrdersOut{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&out))
+
+		page := []uint64{}
+		for _, ord := range out.Orders {
+			page = append(page, ord.ID)
+		}
+		pages = append(pages, page)
+
+		if out.NextCursor == "" {
+			break
+		}
+		url = "/orders?user_id=1&cursor=" + out.NextCursor
+	}
+
+	require.Equal(t, [][]uint64{{5, 4}, {3, 2}, {1}}, pages)
+}
+
+func TestGetOrderByID(t *testing.T) {
+	log := logger.New()
+	ctx := context.Background()
+
+	repo := fake_order.New()
+	repo.Now = func() time.Time { return time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC) }
+	err := repo.Save(ctx, log, &order.Order{
+		Status:      order.CreatedStatus,
+		UserID:      1,
+		PaymentType: order.Card,
+		Items: []order.Item{
+			{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 3},
+		},
+	})
+	require.NoError(t, err)
+
+	uCase := order_ucase.New(repo, fake_item.New(), order_ucase.NoDiscount{}, metrics.Nop{})
+	h := get_order_handler.New(uCase, get_order_handler.PageSize{Default: 10, Max: 100}, log)
+
+	r := mux.NewRouter()
+	r.Handle("/order/{id}", h.GetByID()).Methods("GET")
+
+	cases := []struct {
+		name    string
+		url     string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "found",
+			url:     "/order/1",
+			expCode: http.StatusOK,
+			expBody: `{"id":1,"status":"created","user_id":1,"payment_type":"card","original_amount":300,"discounted_amount":30,"created_at":"2022-05-01T12:00:00Z","items":[{"id":2,"quantity":3,"amount":100,"discounted_amount":10}]}` + "\n",
+		},
+		{
+			name:    "not_found",
+			url:     "/order/2",
+			expCode: http.StatusNotFound,
+			expBody: `{"code":"not_found","message":"order not found"}` + "\n",
+		},
+		{
+			name:    "bad_id",
+			url:     "/order/abc",
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid order ID"]}` + "\n",
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tCase.url, nil)
+
+			r.ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody, string(data))
+		})
+	}
+}
@@ -1,13 +1,14 @@
 package order_handler
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestValidate(t *testing.T) {
-	h := Handler{}
+	h := Handler{pageSize: PageSize{Default: 10, Max: 100}}
 	in := &GetOrdersIn{
 		IDs: []uint64{3, 2, 1},
 	}
@@ -16,10 +17,27 @@ func TestValidate(t *testing.T) {
 }
 
 func TestValidateError(t *testing.T) {
-	h := Handler{}
+	h := Handler{pageSize: PageSize{Default: 10, Max: 100}}
 	in := &GetOrdersIn{
 		IDs: nil,
 	}
 	err := h.validateReq(in)
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrEmptyFilter)
+}
+
+func TestValidateListsAllErrors(t *testing.T) {
+	h := Handler{pageSize: PageSize{Default: 10, Max: 100}}
+	in := &GetOrdersIn{
+		Status:      "lost",
+		PaymentType: "cash",
+		Limit:       101,
+	}
+	err := h.validateReq(in)
+	require.EqualError(t, err, "bad request: invalid status; invalid payment type; limit exceeds max page size: max 100")
+}
+
+func TestParseQueryListsAllErrors(t *testing.T) {
+	query := url.Values{"ids": {"1,x"}, "user_id": {"y"}, "limit": {"0"}}
+	_, err := parseQuery(query)
+	require.EqualError(t, err, `bad query: invalid id "x"; invalid user_id "y"; invalid limit "0"`)
 }
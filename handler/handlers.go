@@ -1,47 +1,103 @@
 package handler
 
 import (
-	"context"
-	"fmt"
-
 	"github.com/ansakharov/lets_test/cmd/config"
+	change_order_status_handler "github.com/ansakharov/lets_test/handler/change_order_status"
 	create_order_handler "github.com/ansakharov/lets_test/handler/create_order"
 	echo_handler "github.com/ansakharov/lets_test/handler/echo"
 	get_orders_handler "github.com/ansakharov/lets_test/handler/get_orders"
-	orderUCase "github.com/ansakharov/lets_test/internal/app/usecase/order"
-	orderRepo "github.com/ansakharov/lets_test/internal/pkg/repository/order"
+	health_handler "github.com/ansakharov/lets_test/handler/health"
+	item_handler "github.com/ansakharov/lets_test/handler/items"
+	metrics_handler "github.com/ansakharov/lets_test/handler/metrics"
+	"github.com/ansakharov/lets_test/handler/middleware"
+	"github.com/ansakharov/lets_test/metrics"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	echoRoute   = "/echo"
-	orderRoute  = "/order"
-	ordersRoute = "/orders"
+	echoRoute         = "/echo"
+	healthzRoute      = "/healthz"
+	readyzRoute       = "/readyz"
+	metricsRoute      = "/metrics"
+	orderRoute        = "/order"
+	orderByIDRoute    = "/order/{id:[0-9]+}"
+	ordersRoute       = "/orders"
+	processOrderRoute = "/order/{id:[0-9]+}/process"
+	cancelOrderRoute  = "/order/{id:[0-9]+}/cancel"
+	itemsRoute        = "/items"
+	itemRoute         = "/items/{id:[0-9]+}"
 )
 
+// Route names, used as keys of http.route_timeouts in config.
+const (
+	echoName         = "echo"
+	healthzName      = "healthz"
+	readyzName       = "readyz"
+	metricsName      = "metrics"
+	createOrderName  = "create_order"
+	getOrderName     = "get_order"
+	getOrdersName    = "get_orders"
+	processOrderName = "process_order"
+	cancelOrderName  = "cancel_order"
+	listItemsName    = "list_items"
+	getItemName      = "get_item"
+	createItemName   = "create_item"
+	updateItemName   = "update_item"
+)
+
+// Handlers are built handlers served by Router.
+type Handlers struct {
+	Health      *health_handler.Handler
+	Metrics     *metrics_handler.Handler
+	CreateOrder *create_order_handler.Handler
+	GetOrders   *get_orders_handler.Handler
+	OrderStatus *change_order_status_handler.Handler
+	Items       *item_handler.Handler
+}
+
 // Router register necessary routes and returns an instance of a router.
-func Router(ctx context.Context, log logrus.FieldLogger, config *config.Config) (*mux.Router, error) {
+// Handlers are built by caller, router only adds middlewares configured by conf.
+// Registry receives http metrics, it's exported by Handlers.Metrics.
+func Router(
+	log logrus.FieldLogger,
+	conf config.HTTP,
+	registry *metrics.Registry,
+	h Handlers,
+) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(middleware.RequestID(log))
+	r.Use(middleware.AccessLog(log, conf.AccessLog.SampleRate, conf.AccessLog.Exclude))
+	r.Use(middleware.Metrics(registry.Registry()))
+	r.Use(middleware.Recover(log, registry))
+	r.Use(middleware.Timeout(conf.RequestTimeout, conf.RouteTimeouts))
 
 	// echo
-	r.HandleFunc(echoRoute, echo_handler.Handler("Your message: ").ServeHTTP).Methods("GET")
+	r.HandleFunc(echoRoute, echo_handler.Handler("Your message: ").ServeHTTP).Methods("GET").Name(echoName)
+
+	// liveness and readiness probes
+	r.HandleFunc(healthzRoute, h.Health.Live().ServeHTTP).Methods("GET").Name(healthzName)
+	r.HandleFunc(readyzRoute, h.Health.Ready().ServeHTTP).Methods("GET").Name(readyzName)
 
-	pool, err := pgxpool.Connect(context.Background(), config.DbConnString)
-	if err != nil {
-		return nil, fmt.Errorf("can't create pg pool: %s", err.Error())
-	}
-	repo := orderRepo.New(pool)
-	orderUCase := orderUCase.New(repo)
+	// prometheus metrics
+	r.HandleFunc(metricsRoute, h.Metrics.Get().ServeHTTP).Methods("GET").Name(metricsName)
 
-	createOrderHandleFunc := create_order_handler.New(orderUCase, log).Create(ctx).ServeHTTP
 	// create order
-	r.HandleFunc(orderRoute, createOrderHandleFunc).Methods("POST")
+	r.HandleFunc(orderRoute, h.CreateOrder.Create().ServeHTTP).Methods("POST").Name(createOrderName)
 
-	getOrderHandlerFunc := get_orders_handler.New(orderUCase, log).Get(ctx).ServeHTTP
 	// get orders
-	r.HandleFunc(ordersRoute, getOrderHandlerFunc).Methods("GET")
+	r.HandleFunc(ordersRoute, h.GetOrders.Get().ServeHTTP).Methods("GET").Name(getOrdersName)
+	r.HandleFunc(orderByIDRoute, h.GetOrders.GetByID().ServeHTTP).Methods("GET").Name(getOrderName)
+
+	// change order status
+	r.HandleFunc(processOrderRoute, h.OrderStatus.Process().ServeHTTP).Methods("POST").Name(processOrderName)
+	r.HandleFunc(cancelOrderRoute, h.OrderStatus.Cancel().ServeHTTP).Methods("POST").Name(cancelOrderName)
+
+	// items catalog
+	r.HandleFunc(itemsRoute, h.Items.List().ServeHTTP).Methods("GET").Name(listItemsName)
+	r.HandleFunc(itemRoute, h.Items.Get().ServeHTTP).Methods("GET").Name(getItemName)
+	r.HandleFunc(itemsRoute, h.Items.Create().ServeHTTP).Methods("POST").Name(createItemName)
+	r.HandleFunc(itemRoute, h.Items.Update().ServeHTTP).Methods("PUT").Name(updateItemName)
 
-	return r, nil
+	return r
 }
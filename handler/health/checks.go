@@ -0,0 +1,34 @@
+package health_handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PoolCheck checks that postgres is reachable.
+func PoolCheck(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) error {
+		if err := pool.Ping(ctx); err != nil {
+			return fmt.Errorf("can't ping postgres: %w", err)
+		}
+		return nil
+	}
+}
+
+// MigrationsCheck checks that schema isn't older than expected version.
+// Newer schema is fine, it's applied before new version of service is rolled out.
+func MigrationsCheck(pool *pgxpool.Pool, expected int64) CheckFunc {
+	return func(ctx context.Context) error {
+		var version int64
+		err := pool.QueryRow(ctx, "select coalesce(max(version), 0) from schema_migrations").Scan(&version)
+		if err != nil {
+			return fmt.Errorf("can't get schema version: %w", err)
+		}
+		if version < expected {
+			return fmt.Errorf("schema version %d is older than expected %d", version, expected)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package health_handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrShuttingDown reported by readiness when service is stopping.
+var ErrShuttingDown = errors.New("service is shutting down")
+
+const (
+	// shutdownCheck is name of check failing after Shutdown.
+	shutdownCheck = "shutdown"
+	// checkTimeout limits time of all readiness checks.
+	checkTimeout = 2 * time.Second
+
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+// CheckFunc returns error if dependency isn't ready.
+type CheckFunc func(ctx context.Context) error
+
+// Handler reports liveness and readiness of service.
+type Handler struct {
+	checks map[string]CheckFunc
+	// shuttingDown is set to 1 by Shutdown.
+	shuttingDown int32
+	log          logrus.FieldLogger
+}
+
+// New gives Handler, checks are run by readiness probe.
+func New(checks map[string]CheckFunc, log logrus.FieldLogger) *Handler {
+	return &Handler{
+		checks: checks,
+		log:    log,
+	}
+}
+
+// Shutdown makes readiness fail, so traffic is not routed to service anymore.
+func (h *Handler) Shutdown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// StatusOut is dto for http resp.
+type StatusOut struct {
+	Status string `json:"status"`
+	// Checks contains result of every dependency check.
+	Checks map[string]CheckOut `json:"checks,omitempty"`
+}
+
+// CheckOut is result of single check.
+type CheckOut struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Live responsible for liveness probe, process is alive while it responds.
+func (h *Handler) Live() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StatusOut{Status: statusOK})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Ready responsible for readiness probe.
+// Responds 503 if any of checks fails or service is shutting down.
+func (h *Handler) Ready() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		out := h.check(ctx)
+
+		code := http.StatusOK
+		if out.Status != statusOK {
+			logger.FromContext(ctx, h.log).WithField("checks", out.Checks).Error("service isn't ready")
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(out)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// check runs all checks concurrently.
+func (h *Handler) check(ctx context.Context) StatusOut {
+	out := StatusOut{
+		Status: statusOK,
+		Checks: make(map[string]CheckOut, len(h.checks)+1),
+	}
+
+	var mu sync.Mutex
+	report := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			out.Status = statusError
+			out.Checks[name] = CheckOut{Status: statusError, Error: err.Error()}
+			return
+		}
+		out.Checks[name] = CheckOut{Status: statusOK}
+	}
+
+	var shutdownErr error
+	if atomic.LoadInt32(&h.shuttingDown) == 1 {
+		shutdownErr = ErrShuttingDown
+	}
+	report(shutdownCheck, shutdownErr)
+
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			report(name, check(ctx))
+		}(name, check)
+	}
+	wg.Wait()
+
+	return out
+}
@@ -0,0 +1,82 @@
+package health_handler_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	health_handler "github.com/ansakharov/lets_test/handler/health"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLive(t *testing.T) {
+	h := health_handler.New(nil, logger.New())
+
+	rec := httptest.NewRecorder()
+	h.Live().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, `{"status":"ok"}`+"\n", string(data))
+}
+
+func TestReady(t *testing.T) {
+	var dbErr error
+	h := health_handler.New(map[string]health_handler.CheckFunc{
+		"postgres":   func(ctx context.Context) error { return dbErr },
+		"migrations": func(ctx context.Context) error { return nil },
+	}, logger.New())
+
+	cases := []struct {
+		name     string
+		dbErr    error
+		shutdown bool
+		expCode  int
+		expBody  string
+	}{
+		{
+			name:    "ready",
+			expCode: http.StatusOK,
+			expBody: `{"status":"ok","checks":{"migrations":{"status":"ok"},"postgres":{"status":"ok"},"shutdown":{"status":"ok"}}}`,
+		},
+		{
+			name:    "db_is_down",
+			dbErr:   errors.New("can't ping postgres: connection refused"),
+			expCode: http.StatusServiceUnavailable,
+			expBody: `{"status":"error","checks":{"migrations":{"status":"ok"},"postgres":{"status":"error","error":"can't ping postgres: connection refused"},"shutdown":{"status":"ok"}}}`,
+		},
+		{
+			name:     "shutting_down",
+			shutdown: true,
+			expCode:  http.StatusServiceUnavailable,
+			expBody:  `{"status":"error","checks":{"migrations":{"status":"ok"},"postgres":{"status":"ok"},"shutdown":{"status":"error","error":"service is shutting down"}}}`,
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			dbErr = tCase.dbErr
+			if tCase.shutdown {
+				h.Shutdown()
+			}
+
+			rec := httptest.NewRecorder()
+			h.Ready().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody+"\n", string(data))
+		})
+	}
+}
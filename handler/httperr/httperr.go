@@ -0,0 +1,72 @@
+package httperr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/logger"
+)
+
+// CodeTimeout is code of requests which didn't fit into deadline.
+const CodeTimeout = "timeout"
+
+// Out is body of error response.
+type Out struct {
+	// Code is kind of error, e.g. validation or not_found.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details lists failures, e.g. all invalid fields of request.
+	Details   []string `json:"details,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+}
+
+var statuses = map[apperr.Kind]int{
+	apperr.Internal:      http.StatusInternalServerError,
+	apperr.Validation:    http.StatusBadRequest,
+	apperr.NotFound:      http.StatusNotFound,
+	apperr.Conflict:      http.StatusConflict,
+	apperr.Unprocessable: http.StatusUnprocessableEntity,
+	apperr.Unavailable:   http.StatusServiceUnavailable,
+}
+
+// Map gives http status and body of err.
+// Text of internal errors isn't exposed, as it may contain details of storage.
+func Map(err error) (int, Out) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, Out{Code: CodeTimeout, Message: "request timed out"}
+	}
+
+	kind := apperr.KindOf(err)
+	out := Out{Code: kind.String()}
+	switch kind {
+	case apperr.Internal:
+		out.Message = "internal server error"
+	case apperr.Unavailable:
+		out.Message = "service unavailable, try again later"
+	default:
+		out.Message = err.Error()
+		var appErr *apperr.Error
+		if errors.As(err, &appErr) && len(appErr.Details()) > 0 {
+			out.Message = appErr.Message()
+			out.Details = appErr.Details()
+		}
+	}
+
+	return statuses[kind], out
+}
+
+// Write responds with err, request id is taken from ctx.
+func Write(ctx context.Context, w http.ResponseWriter, err error) {
+	code, out := Map(err)
+	out.RequestID = logger.RequestID(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	// keep messages like "created -> canceled" readable.
+	enc.SetEscapeHTML(false)
+	enc.Encode(out)
+}
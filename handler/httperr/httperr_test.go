@@ -0,0 +1,84 @@
+package httperr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ansakharov/lets_test/handler/httperr"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	errNotFound := apperr.New(apperr.NotFound, "order not found")
+
+	cases := []struct {
+		name     string
+		err      error
+		status   int
+		expected httperr.Out
+	}{
+		{
+			name:     "validation",
+			err:      apperr.NewValidation("bad request", errors.New("invalid user ID"), errors.New("items can't be empty")),
+			status:   http.StatusBadRequest,
+			expected: httperr.Out{Code: "validation", Message: "bad request", Details: []string{"invalid user ID", "items can't be empty"}},
+		},
+		{
+			name:     "not found",
+			err:      fmt.Errorf("%w: [7 5]", errNotFound),
+			status:   http.StatusNotFound,
+			expected: httperr.Out{Code: "not_found", Message: "order not found: [7 5]"},
+		},
+		{
+			name:     "conflict",
+			err:      apperr.New(apperr.Conflict, "invalid status transition"),
+			status:   http.StatusConflict,
+			expected: httperr.Out{Code: "conflict", Message: "invalid status transition"},
+		},
+		{
+			name:     "unavailable",
+			err:      apperr.Wrap(apperr.Unavailable, "database unavailable", errors.New("connection refused")),
+			status:   http.StatusServiceUnavailable,
+			expected: httperr.Out{Code: "unavailable", Message: "service unavailable, try again later"},
+		},
+		{
+			name:     "timeout",
+			err:      fmt.Errorf("can't select orders: %w", context.DeadlineExceeded),
+			status:   http.StatusGatewayTimeout,
+			expected: httperr.Out{Code: "timeout", Message: "request timed out"},
+		},
+		{
+			name:     "internal text hidden",
+			err:      errors.New(`can't select orders: ERROR: relation "orders" does not exist`),
+			status:   http.StatusInternalServerError,
+			expected: httperr.Out{Code: "internal", Message: "internal server error"},
+		},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			status, out := httperr.Map(tCase.err)
+			require.Equal(t, tCase.status, status)
+			require.Equal(t, tCase.expected, out)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	ctx := logger.WithRequestID(context.Background(), "abc")
+	rr := httptest.NewRecorder()
+	httperr.Write(ctx, rr, apperr.NewValidation("bad request", errors.New("invalid price")))
+
+	body, err := ioutil.ReadAll(rr.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.Equal(t, `{"code":"validation","message":"bad request","details":["invalid price"],"request_id":"abc"}`+"\n", string(body))
+}
@@ -0,0 +1,49 @@
+package item_handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ansakharov/lets_test/handler/httperr"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/logger"
+)
+
+// Create responsible for saving new catalog item.
+func (h Handler) Create() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx, h.log)
+
+		// prepare dto to parse request
+		in := &ItemIn{}
+		// parse req body to dto
+		err := json.NewDecoder(r.Body).Decode(&in)
+		if err != nil {
+			log.WithError(err).Error("can't parse req")
+			httperr.Write(ctx, w, apperr.Wrap(apperr.Validation, "bad json", err))
+			return
+		}
+
+		// check that request valid
+		err = h.validateReq(in)
+		if err != nil {
+			log.WithError(err).Error("bad req")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		it := in.ItemFromDTO(0)
+		err = h.uCase.Create(ctx, log, &it)
+		if err != nil {
+			log.WithError(err).Error("can't create item")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ItemToDTO(it))
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,60 @@
+package item_handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ansakharov/lets_test/handler/httperr"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+)
+
+// List responsible for giving all catalog items.
+func (h Handler) List() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx, h.log)
+
+		items, err := h.uCase.List(ctx, log)
+		if err != nil {
+			log.WithError(err).Error("can't get items")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		out := make([]ItemOut, 0, len(items))
+		for _, it := range items {
+			out = append(out, ItemToDTO(it))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Get responsible for giving single catalog item.
+func (h Handler) Get() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx, h.log)
+
+		ID, err := itemID(r)
+		if err != nil {
+			log.WithField("id", mux.Vars(r)["id"]).WithError(err).Error("bad req")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		it, err := h.uCase.Get(ctx, log, ID)
+		if err != nil {
+			log.WithField("item_id", ID).WithError(err).Error("can't get item")
+			httperr.Write(ctx, w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ItemToDTO(it))
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,96 @@
+package item_handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	item_ucase "github.com/ansakharov/lets_test/internal/app/usecase/item"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Requst validation errors.
+var ErrInvalidItemID = errors.New("invalid item ID")
+var ErrEmptyName = errors.New("name can't be empty")
+var ErrInvalidPrice = errors.New("invalid price")
+var ErrPriceTooBig = fmt.Errorf("price can't exceed %d", maxPrice)
+
+// maxPrice is the biggest price fitting integer columns of items and order_items.
+const maxPrice = math.MaxInt32
+
+// Handler serves items catalog.
+type Handler struct {
+	uCase *item_ucase.Usecase
+	log   logrus.FieldLogger
+}
+
+// New gives Handler.
+func New(
+	uCase *item_ucase.Usecase,
+	log logrus.FieldLogger,
+) *Handler {
+	return &Handler{
+		uCase: uCase,
+		log:   log,
+	}
+}
+
+// ItemIn is dto for http req.
+type ItemIn struct {
+	Name  string `json:"name"`
+	Price uint64 `json:"price"`
+}
+
+// ItemFromDTO creates Item for business layer.
+func (in ItemIn) ItemFromDTO(ID uint64) item.Item {
+	return item.Item{
+		ID:    ID,
+		Name:  in.Name,
+		Price: in.Price,
+	}
+}
+
+// ItemOut is dto for http resp.
+type ItemOut struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Price uint64 `json:"price"`
+}
+
+// ItemToDTO creates ItemOut from business layer Item.
+func ItemToDTO(it item.Item) ItemOut {
+	return ItemOut{
+		ID:    it.ID,
+		Name:  it.Name,
+		Price: it.Price,
+	}
+}
+
+// validates request, all failures are listed in returned error.
+func (h Handler) validateReq(in *ItemIn) error {
+	var errs []error
+	if in.Name == "" {
+		errs = append(errs, ErrEmptyName)
+	}
+	if in.Price == 0 {
+		errs = append(errs, ErrInvalidPrice)
+	}
+	if in.Price > maxPrice {
+		errs = append(errs, ErrPriceTooBig)
+	}
+	return apperr.NewValidation("bad request", errs...)
+}
+
+// itemID extracts item ID from route.
+func itemID(r *http.Request) (uint64, error) {
+	ID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil || ID == 0 {
+		return 0, apperr.NewValidation("bad request", ErrInvalidItemID)
+	}
+	return ID, nil
+}
@@ -0,0 +1,51 @@
+package item_handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	h := Handler{}
+	in := &ItemIn{Name: "premium", Price: 100000}
+	err := h.validateReq(in)
+	require.NoError(t, err)
+}
+
+func TestValidateError(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     *ItemIn
+		expErr error
+	}{
+		{
+			name:   "empty_name",
+			in:     &ItemIn{Price: 1},
+			expErr: ErrEmptyName,
+		},
+		{
+			name:   "bad_price",
+			in:     &ItemIn{Name: "premium"},
+			expErr: ErrInvalidPrice,
+		},
+		{
+			name:   "price_too_big",
+			in:     &ItemIn{Name: "premium", Price: maxPrice + 1},
+			expErr: ErrPriceTooBig,
+		},
+	}
+	h := Handler{}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			err := h.validateReq(tCase.in)
+			require.ErrorIs(t, err, tCase.expErr)
+		})
+	}
+}
+
+func TestValidateListsAllErrors(t *testing.T) {
+	h := Handler{}
+	err := h.validateReq(&ItemIn{})
+	require.EqualError(t, err, "bad request: name can't be empty; invalid price")
+}
@@ -0,0 +1,115 @@
+package item_handler_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	item_handler "github.com/ansakharov/lets_test/handler/items"
+	item_ucase "github.com/ansakharov/lets_test/internal/app/usecase/item"
+	fake_item "github.com/ansakharov/lets_test/internal/pkg/repository/item/fake_item_repo"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemsCatalog(t *testing.T) {
+	log := logger.New()
+
+	uCase := item_ucase.New(fake_item.New())
+	h := item_handler.New(uCase, log)
+
+	r := mux.NewRouter()
+	r.Handle("/items", h.List()).Methods("GET")
+	r.Handle("/items/{id}", h.Get()).Methods("GET")
+	r.Handle("/items", h.Create()).Methods("POST")
+	r.Handle("/items/{id}", h.Update()).Methods("PUT")
+
+	cases := []struct {
+		name    string
+		method  string
+		url     string
+		body    string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "empty_catalog",
+			method:  http.MethodGet,
+			url:     "/items",
+			expCode: http.StatusOK,
+			expBody: "[]\n",
+		},
+		{
+			name:    "create",
+			method:  http.MethodPost,
+			url:     "/items",
+			body:    `{"name": "premium", "price": 100000}`,
+			expCode: http.StatusCreated,
+			expBody: `{"id":1,"name":"premium","price":100000}` + "\n",
+		},
+		{
+			name:    "create_bad_req",
+			method:  http.MethodPost,
+			url:     "/items",
+			body:    `{"name": "limit"}`,
+			expCode: http.StatusBadRequest,
+			expBody: `{"code":"validation","message":"bad request","details":["invalid price"]}` + "\n",
+		},
+		{
+			name:    "update",
+			method:  http.MethodPut,
+			url:     "/items/1",
+			body:    `{"name": "premium", "price": 150000}`,
+			expCode: http.StatusOK,
+			expBody: `{"id":1,"name":"premium","price":150000}` + "\n",
+		},
+		{
+			name:    "update_not_found",
+			method:  http.MethodPut,
+			url:     "/items/2",
+			body:    `{"name": "limit", "price": 1}`,
+			expCode: http.StatusNotFound,
+			expBody: `{"code":"not_found","message":"item not found"}` + "\n",
+		},
+		{
+			name:    "get",
+			method:  http.MethodGet,
+			url:     "/items/1",
+			expCode: http.StatusOK,
+			expBody: `{"id":1,"name":"premium","price":150000}` + "\n",
+		},
+		{
+			name:    "get_not_found",
+			method:  http.MethodGet,
+			url:     "/items/2",
+			expCode: http.StatusNotFound,
+			expBody: `{"code":"not_found","message":"item not found"}` + "\n",
+		},
+		{
+			name:    "list",
+			method:  http.MethodGet,
+			url:     "/items",
+			expCode: http.StatusOK,
+			expBody: `[{"id":1,"name":"premium","price":150000}]` + "\n",
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tCase.method, tCase.url, bytes.NewBufferString(tCase.body))
+
+			r.ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			data, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tCase.expCode, res.StatusCode)
+			require.Equal(t, tCase.expBody, string(data))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package metrics_handler
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/ansakharov/lets_test/handler/httperr"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler exposes metrics
+type Handler struct {
+	registry gometrics.Registry
+	log      logrus.FieldLogger
+}
+
+// New gives Handler.
+func New(
+	registry gometrics.Registry,
+	log logrus.FieldLogger,
+) *Handler {
+	return &Handler{
+		registry: registry,
+		log:      log,
+	}
+}
+
+// Get responsible for giving metrics in prometheus text format.
+func (h Handler) Get() http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		buf := &bytes.Buffer{}
+		if err := metrics.WritePrometheus(buf, h.registry); err != nil {
+			logger.FromContext(r.Context(), h.log).WithError(err).Error("can't write metrics")
+			httperr.Write(r.Context(), w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", metrics.PrometheusContentType)
+		w.Write(buf.Bytes())
+	}
+	return http.HandlerFunc(fn)
+}
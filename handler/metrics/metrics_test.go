@@ -0,0 +1,33 @@
+package metrics_handler_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metrics_handler "github.com/ansakharov/lets_test/handler/metrics"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetrics(t *testing.T) {
+	registry := gometrics.NewRegistry()
+	counter := gometrics.NewCounter()
+	counter.Inc(2)
+	registry.Register(metrics.SaveOrderSuccess, counter)
+
+	rec := httptest.NewRecorder()
+	metrics_handler.New(registry, logger.New()).Get().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, metrics.PrometheusContentType, res.Header.Get("Content-Type"))
+	require.Equal(t, "# TYPE save_order_ok counter\nsave_order_ok 2\n", string(data))
+}
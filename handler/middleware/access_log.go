@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// AccessLog logs method, path template, status, size, duration and remote address
+// of each request. Requests to excluded path templates aren't logged,
+// others are logged with probability of sampleRate, server errors are logged always.
+// Request scoped log is taken from context, it carries request id,
+// so RequestID must go before AccessLog.
+func AccessLog(log logrus.FieldLogger, sampleRate float64, exclude []string) mux.MiddlewareFunc {
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, path := range exclude {
+		excluded[path] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			if _, ok := excluded[route]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			sw := newStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			if sw.status < http.StatusInternalServerError && !sampled(sampleRate) {
+				return
+			}
+			logger.FromContext(r.Context(), log).WithFields(logrus.Fields{
+				"method":      r.Method,
+				"path":        route,
+				"status":      sw.status,
+				"bytes":       sw.size,
+				"duration":    time.Since(start).Seconds(),
+				"remote_addr": r.RemoteAddr,
+			}).Info("request handled")
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// sampled decides whether request is logged.
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	return rand.Float64() < rate
+}
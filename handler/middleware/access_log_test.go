@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ansakharov/lets_test/handler/middleware"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	cases := []struct {
+		name       string
+		sampleRate float64
+		path       string
+		logged     bool
+	}{
+		{name: "logged", sampleRate: 1, path: "/order/1", logged: true},
+		{name: "excluded", sampleRate: 1, path: "/healthz"},
+		{name: "not sampled", sampleRate: 0, path: "/order/1"},
+		{name: "server error logged regardless of sampling", sampleRate: 0, path: "/order/500", logged: true},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			log := logger.New()
+			log.Out = buf
+			log.Formatter = &logrus.JSONFormatter{}
+
+			r := mux.NewRouter()
+			r.Use(middleware.RequestID(log))
+			r.Use(middleware.AccessLog(log, tCase.sampleRate, []string{"/healthz"}))
+			r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+			r.HandleFunc("/order/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+				if mux.Vars(r)["id"] == "500" {
+					http.Error(w, "db is down", http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte("order"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tCase.path, nil)
+			req.Header.Set(middleware.RequestIDHeader, "abc")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !tCase.logged {
+				require.Empty(t, buf.String())
+				return
+			}
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			require.Equal(t, "request handled", entry["msg"])
+			require.Equal(t, "GET", entry["method"])
+			require.Equal(t, "/order/{id:[0-9]+}", entry["path"])
+			// request_id comes from request scoped log set by RequestID.
+			require.Equal(t, "abc", entry["request_id"])
+			require.Equal(t, "192.0.2.1:1234", entry["remote_addr"])
+			require.Contains(t, entry, "duration")
+			if strings.HasSuffix(tCase.path, "500") {
+				require.Equal(t, float64(500), entry["status"])
+				require.Equal(t, float64(len("db is down\n")), entry["bytes"])
+			} else {
+				require.Equal(t, float64(200), entry["status"])
+				require.Equal(t, float64(len("order")), entry["bytes"])
+			}
+		})
+	}
+}
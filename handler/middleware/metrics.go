@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/gorilla/mux"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// Metrics records number, latency and response size of requests labelled by
+// route template, method and status code, and number of in-flight requests.
+func Metrics(registry gometrics.Registry) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+
+			inFlight := registry.GetOrRegister(
+				metrics.Labeled(metrics.HTTPRequestsInFlight, "route", route, "method", r.Method),
+				metrics.NewUpDownGauge,
+			).(*metrics.UpDownGauge)
+			inFlight.Inc(1)
+			defer inFlight.Dec(1)
+
+			start := time.Now()
+			sw := newStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			labels := []string{"route", route, "method", r.Method, "status", strconv.Itoa(sw.status)}
+			registry.GetOrRegister(
+				metrics.Labeled(metrics.HTTPRequests, labels...),
+				gometrics.NewCounter,
+			).(gometrics.Counter).Inc(1)
+			registry.GetOrRegister(
+				metrics.Labeled(metrics.HTTPRequestDuration, labels...),
+				metrics.NewDurationHistogram,
+			).(*metrics.BucketHistogram).UpdateSince(start)
+			registry.GetOrRegister(
+				metrics.Labeled(metrics.HTTPResponseSize, labels...),
+				metrics.NewSizeHistogram,
+			).(*metrics.BucketHistogram).Update(sw.size)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// routeTemplate gives path template of matched route, so ids don't blow up number of metrics.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unknown"
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "unknown"
+	}
+	return template
+}
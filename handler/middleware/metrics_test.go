@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ansakharov/lets_test/handler/middleware"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/gorilla/mux"
+	gometrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := gometrics.NewRegistry()
+
+	var inFlight int64
+	r := mux.NewRouter()
+	r.Use(middleware.Metrics(registry))
+	r.HandleFunc("/order/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		gauge := registry.Get(`http_requests_in_flight{route="/order/{id:[0-9]+}",method="GET"}`)
+		inFlight = gauge.(gometrics.Gauge).Value()
+
+		if mux.Vars(r)["id"] == "2" {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("order"))
+	}).Methods("GET")
+
+	for _, path := range []string{"/order/1", "/order/1", "/order/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, int64(1), inFlight)
+	require.Equal(t, int64(0), registry.Get(`http_requests_in_flight{route="/order/{id:[0-9]+}",method="GET"}`).(gometrics.Gauge).Value())
+
+	ok := `{route="/order/{id:[0-9]+}",method="GET",status="200"}`
+	notFound := `{route="/order/{id:[0-9]+}",method="GET",status="404"}`
+	require.Equal(t, int64(2), registry.Get(metrics.HTTPRequests+ok).(gometrics.Counter).Count())
+	require.Equal(t, int64(1), registry.Get(metrics.HTTPRequests+notFound).(gometrics.Counter).Count())
+	require.Equal(t, int64(2), registry.Get(metrics.HTTPRequestDuration+ok).(gometrics.Histogram).Count())
+	require.Equal(t, int64(10), registry.Get(metrics.HTTPResponseSize+ok).(gometrics.Histogram).Sum())
+	require.Equal(t, int64(len("order not found\n")), registry.Get(metrics.HTTPResponseSize+notFound).(gometrics.Histogram).Sum())
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/ansakharov/lets_test/handler/httperr"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Recover converts panic of handler to 500 response with httperr body, logs it with stack trace
+// and counts it in metrics.HTTPPanics by route.
+// http.ErrAbortHandler is passed through, as it's used to abort response on purpose.
+func Recover(log logrus.FieldLogger, m metrics.Metrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			sw := newStatusWriter(w)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				route := routeTemplate(r)
+				m.IncCounter(metrics.Labeled(metrics.HTTPPanics, "route", route))
+				logger.FromContext(r.Context(), log).WithFields(logrus.Fields{
+					"panic": fmt.Sprint(rec),
+					"stack": string(debug.Stack()),
+				}).Error("handler panicked")
+
+				// part of response is already sent, client gets broken response.
+				if sw.wroteHeader {
+					return
+				}
+				httperr.Write(r.Context(), w, fmt.Errorf("handler panicked: %v", rec))
+			}()
+
+			next.ServeHTTP(sw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ansakharov/lets_test/handler/middleware"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecover(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.New()
+	log.Out = buf
+	recorder := metrics.NewRecorder()
+
+	r := mux.NewRouter()
+	r.Use(middleware.RequestID(log))
+	r.Use(middleware.Recover(log, recorder))
+	r.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		var counter interface{}
+		_ = counter.(int)
+	})
+	r.HandleFunc("/partial", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("part"))
+		panic("boom")
+	})
+	r.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(middleware.RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	body, err := ioutil.ReadAll(rr.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.Equal(t, `{"code":"internal","message":"internal server error","request_id":"abc"}`+"\n", string(body))
+	require.Contains(t, buf.String(), "handler panicked")
+	require.Contains(t, buf.String(), "request_id=abc")
+	require.Contains(t, buf.String(), "recover_test.go")
+	require.Equal(t, int64(1), recorder.Count(`http_panics_total{route="/panic"}`))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "part", rr.Body.String())
+	require.Equal(t, int64(1), recorder.Count(`http_panics_total{route="/partial"}`))
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+	require.Equal(t, int64(0), recorder.Count(`http_panics_total{route="/abort"}`))
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries id of request, it's taken from client or generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen limits length of request id passed by client.
+const maxRequestIDLen = 128
+
+// RequestID propagates X-Request-ID header of request or generates new one,
+// returns it in response and puts it to request context with logger.WithRequestID
+// together with log having request_id and route fields.
+func RequestID(log logrus.FieldLogger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(ID) {
+				ID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, ID)
+
+			ctx := logger.WithRequestID(r.Context(), ID)
+			ctx = logger.ToContext(ctx, log.WithFields(logrus.Fields{
+				"request_id": ID,
+				"route":      routeTemplate(r),
+			}))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// validRequestID checks that client id is safe to log and return in header.
+func validRequestID(ID string) bool {
+	if ID == "" || len(ID) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range ID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID gives random 128 bit id in hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms.
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
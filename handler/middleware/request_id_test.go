@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ansakharov/lets_test/handler/middleware"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.New()
+	log.Out = buf
+
+	var fromCtx string
+	r := mux.NewRouter()
+	r.Use(middleware.RequestID(log))
+	r.HandleFunc("/order/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		fromCtx = logger.RequestID(r.Context())
+		logger.FromContext(r.Context(), nil).Info("handled")
+	})
+
+	cases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "propagated", header: "abc-123", expected: "abc-123"},
+		{name: "generated"},
+		{name: "invalid replaced", header: "bad id\n"},
+		{name: "too long replaced", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/order/1", nil)
+			if tCase.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tCase.header)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			ID := rr.Header().Get(middleware.RequestIDHeader)
+			if tCase.expected != "" {
+				require.Equal(t, tCase.expected, ID)
+			} else {
+				require.Len(t, ID, 32)
+			}
+			require.Equal(t, ID, fromCtx)
+			require.Contains(t, buf.String(), "request_id="+ID)
+			require.Contains(t, buf.String(), `route="/order/{id:[0-9]+}"`)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Timeout sets deadline on request context, so DB queries of slow requests are canceled.
+// Timeouts of routes are looked up by name of matched mux route,
+// routes without own timeout get fallback.
+func Timeout(fallback time.Duration, routes map[string]time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			timeout := fallback
+			if route := mux.CurrentRoute(r); route != nil {
+				if routeTimeout, ok := routes[route.GetName()]; ok {
+					timeout = routeTimeout
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,33 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/handler/middleware"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	deadlines := make(map[string]time.Duration)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		require.True(t, ok)
+		deadlines[r.URL.Path] = time.Until(deadline)
+	}
+
+	r := mux.NewRouter()
+	r.Use(middleware.Timeout(time.Second, map[string]time.Duration{"slow": time.Minute}))
+	r.HandleFunc("/fast", handler).Name("fast")
+	r.HandleFunc("/slow", handler).Name("slow")
+
+	for _, path := range []string{"/fast", "/slow"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.InDelta(t, time.Second, deadlines["/fast"], float64(100*time.Millisecond))
+	require.InDelta(t, time.Minute, deadlines["/slow"], float64(100*time.Millisecond))
+}
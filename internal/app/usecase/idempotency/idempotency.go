@@ -0,0 +1,94 @@
+package idempotency_ucase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/idempotency"
+	keyRepo "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrKeyReused returned when key was already used with another request body.
+var ErrKeyReused = apperr.New(apperr.Unprocessable, "idempotency key was used with another request")
+
+// ErrInProgress returned when request with the same key is being processed.
+var ErrInProgress = apperr.New(apperr.Conflict, "request with idempotency key is in progress")
+
+// Usecase deduplicates requests by idempotency keys.
+type Usecase struct {
+	repo  keyRepo.KeyRepo
+	ttl   time.Duration
+	lease time.Duration
+	now   func() time.Time
+}
+
+// New gives Usecase, results are kept for ttl.
+// Key in progress is held for lease, after that abandoned key can be reserved again.
+func New(keyRepo keyRepo.KeyRepo, ttl, lease time.Duration) *Usecase {
+	return &Usecase{
+		repo:  keyRepo,
+		ttl:   ttl,
+		lease: lease,
+		now:   time.Now,
+	}
+}
+
+// Begin reserves key for request with given body.
+// If request with the same key and body was completed, its stored result returned.
+func (uc *Usecase) Begin(ctx context.Context, log logrus.FieldLogger, key string, body []byte) (*idempotency.Record, error) {
+	rec := idempotency.Record{
+		Key:         key,
+		RequestHash: hash(body),
+		// result isn't stored yet, so key is held only for lease.
+		ExpiresAt: uc.now().Add(uc.lease),
+	}
+
+	existing, created, err := uc.repo.Reserve(ctx, log, rec)
+	if err != nil {
+		return nil, fmt.Errorf("err from idempotency_repository: %w", err)
+	}
+	if created {
+		return nil, nil
+	}
+
+	if existing.RequestHash != rec.RequestHash {
+		return nil, ErrKeyReused
+	}
+	if !existing.Completed() {
+		return nil, ErrInProgress
+	}
+
+	return &existing, nil
+}
+
+// Finish stores result of request with given body made with key, it's kept for ttl.
+// Result isn't stored if key was taken by retry after lease expired.
+func (uc *Usecase) Finish(ctx context.Context, log logrus.FieldLogger, body []byte, rec idempotency.Record) error {
+	rec.RequestHash = hash(body)
+	rec.ExpiresAt = uc.now().Add(uc.ttl)
+	if err := uc.repo.Complete(ctx, log, rec); err != nil {
+		return fmt.Errorf("err from idempotency_repository: %w", err)
+	}
+
+	return nil
+}
+
+// Abort releases key reserved by request with given body, so request can be retried.
+// Key taken by retry after lease expired is kept.
+func (uc *Usecase) Abort(ctx context.Context, log logrus.FieldLogger, key string, body []byte) error {
+	if err := uc.repo.Release(ctx, log, key, hash(body)); err != nil {
+		return fmt.Errorf("err from idempotency_repository: %w", err)
+	}
+
+	return nil
+}
+
+func hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
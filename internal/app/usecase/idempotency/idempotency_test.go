@@ -0,0 +1,133 @@
+package idempotency_ucase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/idempotency"
+	keyRepo "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency"
+	fake_idempotency "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency/fake_idempotency_repo"
+	repoMock "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency/mocks"
+	log "github.com/ansakharov/lets_test/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBegin(t *testing.T) {
+	ctx := context.Background()
+	log := log.New()
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"user_id": 1}`)
+
+	reserved := idempotency.Record{
+		Key:         "key",
+		RequestHash: hash(body),
+		ExpiresAt:   now.Add(time.Hour),
+	}
+	completed := reserved
+	completed.StatusCode = 200
+	completed.Body = []byte(`{"success":"ok"}`)
+
+	cases := []struct {
+		name     string
+		existing idempotency.Record
+		created  bool
+		expRec   *idempotency.Record
+		expErr   error
+	}{
+		{name: "new_key", existing: reserved, created: true},
+		{name: "completed", existing: completed, expRec: &completed},
+		{name: "in_progress", existing: reserved, expErr: ErrInProgress},
+		{
+			name:     "another_body",
+			existing: idempotency.Record{Key: "key", RequestHash: hash([]byte("{}")), StatusCode: 200},
+			expErr:   ErrKeyReused,
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			repo := repoMock.NewMockKeyRepo(ctl)
+			repo.EXPECT().Reserve(ctx, log, reserved).Return(tCase.existing, tCase.created, nil).Times(1)
+
+			Usecase := New(repo, 24*time.Hour, time.Hour)
+			Usecase.now = func() time.Time { return now }
+			rec, err := Usecase.Begin(ctx, log, "key", body)
+			if tCase.expErr != nil {
+				require.ErrorIs(t, err, tCase.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tCase.expRec, rec)
+		})
+	}
+}
+
+func TestBeginError(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockKeyRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	repo.EXPECT().Reserve(ctx, log, gomock.Any()).Return(idempotency.Record{}, false, errors.New("db is down")).Times(1)
+
+	Usecase := New(repo, 24*time.Hour, time.Hour)
+	rec, err := Usecase.Begin(ctx, log, "key", nil)
+	require.EqualError(t, err, "err from idempotency_repository: db is down")
+	require.Nil(t, rec)
+}
+
+func TestFinishKeepsResultForTTL(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	ctx := context.Background()
+	log := log.New()
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	body := []byte(`{"user_id": 1}`)
+
+	repo := repoMock.NewMockKeyRepo(ctl)
+	repo.EXPECT().Complete(ctx, log, idempotency.Record{
+		Key:         "key",
+		RequestHash: hash(body),
+		StatusCode:  201,
+		ExpiresAt:   now.Add(24 * time.Hour),
+	}).Return(nil).Times(1)
+
+	Usecase := New(repo, 24*time.Hour, time.Hour)
+	Usecase.now = func() time.Time { return now }
+	require.NoError(t, Usecase.Finish(ctx, log, body, idempotency.Record{Key: "key", StatusCode: 201}))
+}
+
+func TestStaleRequestKeepsRetryReservation(t *testing.T) {
+	ctx := context.Background()
+	log := log.New()
+	first, retry := []byte(`{"user_id": 1}`), []byte(`{"user_id": 2}`)
+
+	Usecase := New(fake_idempotency.New(), 24*time.Hour, time.Minute)
+	// lease of the first request is already expired, so retry takes key.
+	Usecase.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	rec, err := Usecase.Begin(ctx, log, "key", first)
+	require.NoError(t, err)
+	require.Nil(t, rec)
+
+	Usecase.now = time.Now
+	rec, err = Usecase.Begin(ctx, log, "key", retry)
+	require.NoError(t, err)
+	require.Nil(t, rec)
+
+	// slow first request finishes after retry took key.
+	err = Usecase.Finish(ctx, log, first, idempotency.Record{Key: "key", StatusCode: 201})
+	require.ErrorIs(t, err, keyRepo.ErrNotReserved)
+	require.NoError(t, Usecase.Abort(ctx, log, "key", first))
+
+	_, err = Usecase.Begin(ctx, log, "key", retry)
+	require.ErrorIs(t, err, ErrInProgress)
+}
@@ -0,0 +1,71 @@
+package item_ucase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	itemRepo "github.com/ansakharov/lets_test/internal/pkg/repository/item"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrItemNotFound returned when requested item doesn't exist.
+var ErrItemNotFound = apperr.New(apperr.NotFound, "item not found")
+
+// Usecase responsible for items catalog.
+type Usecase struct {
+	repo itemRepo.ItemRepo
+}
+
+// New gives Usecase.
+func New(itemRepo itemRepo.ItemRepo) *Usecase {
+	return &Usecase{repo: itemRepo}
+}
+
+// List all catalog items.
+func (uc *Usecase) List(ctx context.Context, log logrus.FieldLogger) ([]item.Item, error) {
+	items, err := uc.repo.List(ctx, log)
+	if err != nil {
+		return nil, fmt.Errorf("err from items_repository: %w", err)
+	}
+
+	return items, nil
+}
+
+// Get single item by id.
+func (uc *Usecase) Get(ctx context.Context, log logrus.FieldLogger, ID uint64) (item.Item, error) {
+	items, err := uc.repo.Get(ctx, log, []uint64{ID})
+	if err != nil {
+		return item.Item{}, fmt.Errorf("err from items_repository: %w", err)
+	}
+	it, ok := items[ID]
+	if !ok {
+		return item.Item{}, ErrItemNotFound
+	}
+
+	return it, nil
+}
+
+// Create new catalog item.
+func (uc *Usecase) Create(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	if err := uc.repo.Save(ctx, log, item); err != nil {
+		return fmt.Errorf("err from items_repository: %w", err)
+	}
+
+	return nil
+}
+
+// Update existing catalog item.
+func (uc *Usecase) Update(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	err := uc.repo.Update(ctx, log, item)
+	if errors.Is(err, itemRepo.ErrItemNotFound) {
+		return ErrItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("err from items_repository: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package item_ucase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	itemRepo "github.com/ansakharov/lets_test/internal/pkg/repository/item"
+	repoMock "github.com/ansakharov/lets_test/internal/pkg/repository/item/mocks"
+	log "github.com/ansakharov/lets_test/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockItemRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	premium := item.Item{ID: 1, Name: "premium", Price: 100000}
+	repo.EXPECT().Get(ctx, log, []uint64{1}).Return(map[uint64]item.Item{1: premium}, nil).Times(1)
+
+	Usecase := New(repo)
+	it, err := Usecase.Get(ctx, log, 1)
+	require.NoError(t, err)
+	require.Equal(t, premium, it)
+}
+
+func TestGetNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockItemRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	repo.EXPECT().Get(ctx, log, []uint64{1}).Return(map[uint64]item.Item{}, nil).Times(1)
+
+	Usecase := New(repo)
+	_, err := Usecase.Get(ctx, log, 1)
+	require.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestListError(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockItemRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	repo.EXPECT().List(ctx, log).Return(nil, errors.New("db is down")).Times(1)
+
+	Usecase := New(repo)
+	items, err := Usecase.List(ctx, log)
+	require.EqualError(t, err, "err from items_repository: db is down")
+	require.Nil(t, items)
+}
+
+func TestUpdateNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockItemRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	in := &item.Item{ID: 5, Name: "premium", Price: 1}
+	repo.EXPECT().Update(ctx, log, in).Return(itemRepo.ErrItemNotFound).Times(1)
+
+	Usecase := New(repo)
+	err := Usecase.Update(ctx, log, in)
+	require.ErrorIs(t, err, ErrItemNotFound)
+}
@@ -0,0 +1,34 @@
+package create_order
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrDiscountRejected wrapped by DiscountSource errors caused by bad request,
+// e.g. unknown or expired promo code.
+var ErrDiscountRejected = apperr.New(apperr.Unprocessable, "discount rejected")
+
+// DiscountSource gives server-side discounts for priced order items.
+type DiscountSource interface {
+	// Apply sets DiscountedAmount of every order item.
+	Apply(ctx context.Context, log logrus.FieldLogger, ord *order.Order) error
+}
+
+// NoDiscount is DiscountSource which never gives a discount.
+type NoDiscount struct{}
+
+// Apply resets discounts of all order items.
+func (NoDiscount) Apply(ctx context.Context, log logrus.FieldLogger, ord *order.Order) error {
+	if ord.PromoCode != "" {
+		return fmt.Errorf("%w: promo codes are not supported", ErrDiscountRejected)
+	}
+	for idx := range ord.Items {
+		ord.Items[idx].DiscountedAmount = 0
+	}
+	return nil
+}
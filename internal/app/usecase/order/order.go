@@ -2,62 +2,278 @@ package create_order
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	itemRepo "github.com/ansakharov/lets_test/internal/pkg/repository/item"
 	orderRepo "github.com/ansakharov/lets_test/internal/pkg/repository/order"
 	"github.com/ansakharov/lets_test/metrics"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrOrderNotFound returned when requested order doesn't exist.
+var ErrOrderNotFound = apperr.New(apperr.NotFound, "order not found")
+
+// ErrInvalidTransition returned when order can't be moved to requested status.
+var ErrInvalidTransition = apperr.New(apperr.Conflict, "invalid status transition")
+
+// UnknownItemsError returned when order contains items absent in catalog.
+type UnknownItemsError struct {
+	IDs []uint64
+}
+
+func (e *UnknownItemsError) Error() string {
+	return fmt.Sprintf("unknown items: %v", e.IDs)
+}
+
+// Kind tells that order can't be created with such items.
+func (e *UnknownItemsError) Kind() apperr.Kind {
+	return apperr.Unprocessable
+}
+
 // Usecase responsible for saving request.
 type Usecase struct {
-	repo orderRepo.OrderRepo
+	repo      orderRepo.OrderRepo
+	itemRepo  itemRepo.ItemRepo
+	discounts DiscountSource
+	metrics   metrics.Metrics
 }
 
 // New gives Usecase.
-func New(orderRepo orderRepo.OrderRepo) *Usecase {
-	return &Usecase{repo: orderRepo}
+func New(
+	orderRepo orderRepo.OrderRepo,
+	itemRepo itemRepo.ItemRepo,
+	discounts DiscountSource,
+	m metrics.Metrics,
+) *Usecase {
+	return &Usecase{
+		repo:      orderRepo,
+		itemRepo:  itemRepo,
+		discounts: discounts,
+		metrics:   m,
+	}
 }
 
 // Save single order.
+// Item amounts are taken from catalog, discounts from DiscountSource.
 func (uc *Usecase) Save(ctx context.Context, log logrus.FieldLogger, order *order.Order) error {
+	if err := uc.price(ctx, log, order); err != nil {
+		uc.metrics.IncCounter(metrics.SaveOrderError)
+		uc.metrics.IncCounter(metrics.SaveOrderCount)
+
+		return err
+	}
+
 	if err := uc.repo.Save(ctx, log, order); err != nil {
-		metrics.IncCounter(metrics.SaveOrderError)
-		metrics.IncCounter(metrics.SaveOrderCount)
+		uc.metrics.IncCounter(metrics.SaveOrderError)
+		uc.metrics.IncCounter(metrics.SaveOrderCount)
 
+		if errors.Is(err, orderRepo.ErrPromoCodeUnavailable) {
+			return fmt.Errorf("%w: %s", ErrDiscountRejected, err.Error())
+		}
 		return err
 	}
 
-	metrics.IncCounter(metrics.SaveOrderSuccess)
-	metrics.IncCounter(metrics.SaveOrderCount)
+	countAmounts(order)
+
+	uc.metrics.IncCounter(metrics.SaveOrderSuccess)
+	uc.metrics.IncCounter(metrics.SaveOrderCount)
 
 	return nil
 }
 
-// Get orders by ids.
-func (uc *Usecase) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) ([]order.Order, error) {
-	ordersMap, err := uc.repo.Get(ctx, log, IDs)
+// Get orders matching filter.
+// Orders go in order of filter.IDs, requested IDs without orders are returned as notFound.
+// Without IDs orders are sorted by ID.
+func (uc *Usecase) Get(ctx context.Context, log logrus.FieldLogger, filter order.Filter) ([]order.Order, []uint64, error) {
+	ordersMap, err := uc.repo.Get(ctx, log, filter)
 	if err != nil {
-		metrics.IncCounter(metrics.GetOrdersError)
-		metrics.IncCounter(metrics.GetOrdersCount)
-		return nil, fmt.Errorf("err from orders_repository: %s", err.Error())
+		uc.metrics.IncCounter(metrics.GetOrdersError)
+		uc.metrics.IncCounter(metrics.GetOrdersCount)
+		return nil, nil, fmt.Errorf("err from orders_repository: %w", err)
 	}
 
-	// count amount and discount for all orders.
-	for idx, singleOrder := range ordersMap {
-		for _, singleService := range singleOrder.Items {
-			singleOrder.OriginalAmount += singleService.Amount
-			singleOrder.DiscountedAmount += singleService.DiscountedAmount
-			ordersMap[idx] = singleOrder
+	result := make([]order.Order, 0, len(ordersMap))
+	notFound := []uint64{}
+	if len(filter.IDs) == 0 {
+		for _, order := range ordersMap {
+			result = append(result, order)
 		}
+		sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
 	}
-	result := make([]order.Order, 0, len(ordersMap))
-	for _, order := range ordersMap {
+
+	seen := make(map[uint64]struct{}, len(filter.IDs))
+	for _, ID := range filter.IDs {
+		if _, dup := seen[ID]; dup {
+			continue
+		}
+		seen[ID] = struct{}{}
+
+		order, ok := ordersMap[ID]
+		if !ok {
+			notFound = append(notFound, ID)
+			continue
+		}
 		result = append(result, order)
 	}
 
-	metrics.IncCounter(metrics.GetOrdersSuccess)
-	metrics.IncCounter(metrics.GetOrdersCount)
-	return result, nil
+	for idx := range result {
+		countAmounts(&result[idx])
+	}
+
+	uc.metrics.IncCounter(metrics.GetOrdersSuccess)
+	uc.metrics.IncCounter(metrics.GetOrdersCount)
+	return result, notFound, nil
+}
+
+// List gives page of orders matching filter from newest to oldest.
+// Page starts after cursor, nil cursor means first page.
+// Returned cursor points to the last order of the page, it's nil if there are no more orders.
+func (uc *Usecase) List(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	filter order.Filter,
+	after *order.Cursor,
+	limit uint64,
+) ([]order.Order, *order.Cursor, error) {
+	// one extra order tells whether next page exists.
+	orders, err := uc.repo.List(ctx, log, filter, after, limit+1)
+	if err != nil {
+		uc.metrics.IncCounter(metrics.GetOrdersError)
+		uc.metrics.IncCounter(metrics.GetOrdersCount)
+		return nil, nil, fmt.Errorf("err from orders_repository: %w", err)
+	}
+
+	var next *order.Cursor
+	if uint64(len(orders)) > limit {
+		orders = orders[:limit]
+		cursor := order.CursorOf(orders[len(orders)-1])
+		next = &cursor
+	}
+	for idx := range orders {
+		countAmounts(&orders[idx])
+	}
+
+	uc.metrics.IncCounter(metrics.GetOrdersSuccess)
+	uc.metrics.IncCounter(metrics.GetOrdersCount)
+	return orders, next, nil
+}
+
+// GetByID gives single order.
+func (uc *Usecase) GetByID(ctx context.Context, log logrus.FieldLogger, ID uint64) (order.Order, error) {
+	ord, err := uc.getOne(ctx, log, ID)
+	if err != nil {
+		return order.Order{}, err
+	}
+	countAmounts(&ord)
+
+	return ord, nil
+}
+
+// getOne gives order from repository, ErrOrderNotFound if it's missing.
+func (uc *Usecase) getOne(ctx context.Context, log logrus.FieldLogger, ID uint64) (order.Order, error) {
+	ordersMap, err := uc.repo.Get(ctx, log, order.Filter{IDs: []uint64{ID}})
+	if err != nil {
+		return order.Order{}, fmt.Errorf("err from orders_repository: %w", err)
+	}
+	ord, ok := ordersMap[ID]
+	if !ok {
+		return order.Order{}, ErrOrderNotFound
+	}
+
+	return ord, nil
+}
+
+// Process marks order as processed.
+func (uc *Usecase) Process(ctx context.Context, log logrus.FieldLogger, ID uint64) (order.Order, error) {
+	return uc.changeStatus(ctx, log, ID, order.ProcessedStatus)
+}
+
+// Cancel marks order as canceled.
+func (uc *Usecase) Cancel(ctx context.Context, log logrus.FieldLogger, ID uint64) (order.Order, error) {
+	return uc.changeStatus(ctx, log, ID, order.CanceledStatus)
+}
+
+// changeStatus moves order to the given status if transition is allowed.
+func (uc *Usecase) changeStatus(ctx context.Context, log logrus.FieldLogger, ID uint64, to order.Status) (order.Order, error) {
+	ord, err := uc.getOne(ctx, log, ID)
+	if err != nil {
+		return order.Order{}, err
+	}
+
+	if !ord.Status.CanTransitionTo(to) {
+		return order.Order{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, ord.Status, to)
+	}
+
+	err = uc.repo.UpdateStatus(ctx, log, ID, ord.Status, to)
+	if errors.Is(err, orderRepo.ErrStatusMismatch) {
+		// order was changed concurrently.
+		log.WithField("order_id", ID).Warn("order status changed concurrently")
+		return order.Order{}, fmt.Errorf("%w: order status changed concurrently", ErrInvalidTransition)
+	}
+	if err != nil {
+		return order.Order{}, fmt.Errorf("err from orders_repository: %w", err)
+	}
+
+	ord.Status = to
+	countAmounts(&ord)
+
+	return ord, nil
+}
+
+// price fills order items amounts from catalog and applies discounts.
+func (uc *Usecase) price(ctx context.Context, log logrus.FieldLogger, ord *order.Order) error {
+	if len(ord.Items) == 0 {
+		return nil
+	}
+
+	IDs := make([]uint64, 0, len(ord.Items))
+	for _, item := range ord.Items {
+		IDs = append(IDs, item.ID)
+	}
+	catalog, err := uc.itemRepo.Get(ctx, log, IDs)
+	if err != nil {
+		return fmt.Errorf("err from items_repository: %w", err)
+	}
+
+	unknown := []uint64{}
+	seen := make(map[uint64]struct{})
+	for idx, item := range ord.Items {
+		catalogItem, ok := catalog[item.ID]
+		if !ok {
+			if _, dup := seen[item.ID]; !dup {
+				unknown = append(unknown, item.ID)
+				seen[item.ID] = struct{}{}
+			}
+			continue
+		}
+		ord.Items[idx].Amount = catalogItem.Price
+		ord.Items[idx].DiscountedAmount = 0
+	}
+	if len(unknown) > 0 {
+		return &UnknownItemsError{IDs: unknown}
+	}
+
+	if err := uc.discounts.Apply(ctx, log, ord); err != nil {
+		return fmt.Errorf("can't apply discounts: %w", err)
+	}
+	for _, item := range ord.Items {
+		if item.DiscountedAmount > item.Amount {
+			return fmt.Errorf("discount %d exceeds price %d of item %d", item.DiscountedAmount, item.Amount, item.ID)
+		}
+	}
+
+	return nil
+}
+
+// countAmounts sums amount and discount of order items.
+func countAmounts(ord *order.Order) {
+	ord.OriginalAmount, ord.DiscountedAmount = 0, 0
+	for _, item := range ord.Items {
+		ord.OriginalAmount += item.Amount * item.Quantity
+		ord.DiscountedAmount += item.DiscountedAmount * item.Quantity
+	}
 }
@@ -5,8 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	fake_item "github.com/ansakharov/lets_test/internal/pkg/repository/item/fake_item_repo"
+	itemMock "github.com/ansakharov/lets_test/internal/pkg/repository/item/mocks"
+	orderRepo "github.com/ansakharov/lets_test/internal/pkg/repository/order"
 	repoMock "github.com/ansakharov/lets_test/internal/pkg/repository/order/mocks"
 	log "github.com/ansakharov/lets_test/logger"
 	"github.com/ansakharov/lets_test/metrics"
@@ -15,7 +20,7 @@ import (
 )
 
 func TestGet(t *testing.T) {
-	metrics.Init()
+	recorder := metrics.NewRecorder()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -24,22 +29,22 @@ func TestGet(t *testing.T) {
 
 	ctx := context.Background()
 	log := log.New()
-	in := []uint64{1, 2, 3}
+	in := order.Filter{IDs: []uint64{1, 2, 3}}
 
 	mockResp := map[uint64]order.Order{
 		1: {
 			ID:          1,
 			PaymentType: 1,
 			Items: []order.Item{
-				{ID: 2, Amount: 100, DiscountedAmount: 10},
-				{ID: 3, Amount: 1000, DiscountedAmount: 20},
+				{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 1},
+				{ID: 3, Amount: 1000, DiscountedAmount: 20, Quantity: 2},
 			},
 		},
 		2: {
 			ID:          2,
 			PaymentType: 1,
 			Items: []order.Item{
-				{ID: 2, Amount: 100, DiscountedAmount: 10},
+				{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 1},
 			},
 		},
 	}
@@ -48,11 +53,11 @@ func TestGet(t *testing.T) {
 		{
 			ID:               1,
 			PaymentType:      1,
-			OriginalAmount:   1100,
-			DiscountedAmount: 30,
+			OriginalAmount:   2100,
+			DiscountedAmount: 50,
 			Items: []order.Item{
-				{ID: 2, Amount: 100, DiscountedAmount: 10},
-				{ID: 3, Amount: 1000, DiscountedAmount: 20},
+				{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 1},
+				{ID: 3, Amount: 1000, DiscountedAmount: 20, Quantity: 2},
 			},
 		},
 		{
@@ -61,20 +66,22 @@ func TestGet(t *testing.T) {
 			OriginalAmount:   100,
 			DiscountedAmount: 10,
 			Items: []order.Item{
-				{ID: 2, Amount: 100, DiscountedAmount: 10},
+				{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 1},
 			},
 		},
 	}
 	repo.EXPECT().Get(ctx, log, in).Return(mockResp, nil).Times(1)
 
-	Usecase := New(repo)
-	orders, err := Usecase.Get(ctx, log, in)
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, recorder)
+	orders, notFound, err := Usecase.Get(ctx, log, in)
 	require.NoError(t, err)
-	require.ElementsMatch(t, expected, orders)
+	require.Equal(t, expected, orders)
+	require.Equal(t, []uint64{3}, notFound)
+	require.Equal(t, map[string]int64{metrics.GetOrdersSuccess: 1, metrics.GetOrdersCount: 1}, recorder.Counters())
 }
 
 func TestGetError(t *testing.T) {
-	metrics.Init()
+	recorder := metrics.NewRecorder()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -84,21 +91,106 @@ func TestGetError(t *testing.T) {
 	repoErr := errors.New("db is down")
 	ctx := context.Background()
 	log := log.New()
-	in := []uint64{1, 2, 3}
+	in := order.Filter{IDs: []uint64{1, 2, 3}}
 	repo.EXPECT().Get(ctx, log, in).Return(nil, repoErr).Times(1)
 
-	Usecase := New(repo)
-	orders, err := Usecase.Get(ctx, log, in)
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, recorder)
+	orders, notFound, err := Usecase.Get(ctx, log, in)
 	require.Error(t, err)
 	require.EqualError(t,
 		fmt.Errorf("err from orders_repository: %s", repoErr.Error()),
 		err.Error(),
 	)
 	require.Nil(t, orders)
+	require.Nil(t, notFound)
+	require.Equal(t, map[string]int64{metrics.GetOrdersError: 1, metrics.GetOrdersCount: 1}, recorder.Counters())
+}
+
+func TestGetKeepsRequestedOrder(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	in := order.Filter{IDs: []uint64{5, 1, 9, 5, 3}}
+	mockResp := map[uint64]order.Order{
+		1: {ID: 1},
+		3: {ID: 3},
+		5: {ID: 5},
+	}
+	repo.EXPECT().Get(ctx, log, in).Return(mockResp, nil).Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, metrics.Nop{})
+	orders, notFound, err := Usecase.Get(ctx, log, in)
+	require.NoError(t, err)
+	require.Equal(t, []order.Order{{ID: 5}, {ID: 1}, {ID: 3}}, orders)
+	require.Equal(t, []uint64{9}, notFound)
+}
+
+func TestList(t *testing.T) {
+	recorder := metrics.NewRecorder()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	filter := order.Filter{UserID: 1}
+	createdAt := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	after := &order.Cursor{CreatedAt: createdAt, ID: 10}
+
+	mockResp := []order.Order{
+		{ID: 9, UserID: 1, CreatedAt: createdAt, Items: []order.Item{{ID: 2, Amount: 100, Quantity: 2}}},
+		{ID: 7, UserID: 1, CreatedAt: createdAt.Add(-time.Hour)},
+		{ID: 3, UserID: 1, CreatedAt: createdAt.Add(-2 * time.Hour)},
+	}
+	// one more than requested to detect next page.
+	repo.EXPECT().List(ctx, log, filter, after, uint64(3)).Return(mockResp, nil).Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, recorder)
+	orders, next, err := Usecase.List(ctx, log, filter, after, 2)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	require.Equal(t, uint64(200), orders[0].OriginalAmount)
+	require.Equal(t, &order.Cursor{CreatedAt: createdAt.Add(-time.Hour), ID: 7}, next)
+
+	// last page.
+	repo.EXPECT().List(ctx, log, filter, next, uint64(3)).Return(mockResp[2:], nil).Times(1)
+
+	orders, next, err = Usecase.List(ctx, log, filter, next, 2)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Nil(t, next)
+	require.Equal(t, map[string]int64{metrics.GetOrdersSuccess: 2, metrics.GetOrdersCount: 2}, recorder.Counters())
+}
+
+func TestListError(t *testing.T) {
+	recorder := metrics.NewRecorder()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	repoErr := errors.New("db is down")
+	ctx := context.Background()
+	log := log.New()
+	repo.EXPECT().List(ctx, log, order.Filter{}, nil, uint64(11)).Return(nil, repoErr).Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, recorder)
+	orders, next, err := Usecase.List(ctx, log, order.Filter{}, nil, 10)
+	require.EqualError(t, err, "err from orders_repository: db is down")
+	require.Nil(t, orders)
+	require.Nil(t, next)
+	require.Equal(t, map[string]int64{metrics.GetOrdersError: 1, metrics.GetOrdersCount: 1}, recorder.Counters())
 }
 
 func TestSaveError(t *testing.T) {
-	metrics.Init()
+	recorder := metrics.NewRecorder()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -111,13 +203,14 @@ func TestSaveError(t *testing.T) {
 	in := &order.Order{}
 	repo.EXPECT().Save(ctx, log, in).Return(repoErr).Times(1)
 
-	Usecase := New(repo)
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, recorder)
 	err := Usecase.Save(ctx, log, in)
 	require.Error(t, err)
+	require.Equal(t, map[string]int64{metrics.SaveOrderError: 1, metrics.SaveOrderCount: 1}, recorder.Counters())
 }
 
 func TestSave(t *testing.T) {
-	metrics.Init()
+	recorder := metrics.NewRecorder()
 
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -129,7 +222,154 @@ func TestSave(t *testing.T) {
 	in := &order.Order{}
 	repo.EXPECT().Save(ctx, log, in).Return(nil).Times(1)
 
-	Usecase := New(repo)
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, recorder)
+	err := Usecase.Save(ctx, log, in)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{metrics.SaveOrderSuccess: 1, metrics.SaveOrderCount: 1}, recorder.Counters())
+}
+
+func TestProcess(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	mockResp := map[uint64]order.Order{
+		1: {
+			ID:     1,
+			Status: order.CreatedStatus,
+			Items: []order.Item{
+				{ID: 2, Amount: 100, DiscountedAmount: 10, Quantity: 1},
+			},
+		},
+	}
+	repo.EXPECT().Get(ctx, log, order.Filter{IDs: []uint64{1}}).Return(mockResp, nil).Times(1)
+	repo.EXPECT().UpdateStatus(ctx, log, uint64(1), order.CreatedStatus, order.ProcessedStatus).Return(nil).Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, metrics.Nop{})
+	ord, err := Usecase.Process(ctx, log, 1)
+	require.NoError(t, err)
+	require.Equal(t, order.ProcessedStatus, ord.Status)
+	require.Equal(t, uint64(100), ord.OriginalAmount)
+	require.Equal(t, uint64(10), ord.DiscountedAmount)
+}
+
+func TestCancelNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	repo.EXPECT().Get(ctx, log, order.Filter{IDs: []uint64{1}}).Return(map[uint64]order.Order{}, nil).Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, metrics.Nop{})
+	_, err := Usecase.Cancel(ctx, log, 1)
+	require.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestChangeStatusInvalidTransition(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	mockResp := map[uint64]order.Order{
+		1: {ID: 1, Status: order.CanceledStatus},
+	}
+	repo.EXPECT().Get(ctx, log, order.Filter{IDs: []uint64{1}}).Return(mockResp, nil).Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, metrics.Nop{})
+	_, err := Usecase.Process(ctx, log, 1)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestChangeStatusConcurrentUpdate(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	mockResp := map[uint64]order.Order{
+		1: {ID: 1, Status: order.CreatedStatus},
+	}
+	repo.EXPECT().Get(ctx, log, order.Filter{IDs: []uint64{1}}).Return(mockResp, nil).Times(1)
+	repo.EXPECT().
+		UpdateStatus(ctx, log, uint64(1), order.CreatedStatus, order.CanceledStatus).
+		Return(orderRepo.ErrStatusMismatch).
+		Times(1)
+
+	Usecase := New(repo, fake_item.New(), NoDiscount{}, metrics.Nop{})
+	_, err := Usecase.Cancel(ctx, log, 1)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestSavePricesItems(t *testing.T) {
+	recorder := metrics.NewRecorder()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+	items := itemMock.NewMockItemRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	in := &order.Order{
+		Items: []order.Item{
+			{ID: 1, Amount: 1, DiscountedAmount: 1, Quantity: 1},
+			{ID: 2, Quantity: 3},
+		},
+	}
+	expected := &order.Order{
+		Items: []order.Item{
+			{ID: 1, Amount: 100000, Quantity: 1},
+			{ID: 2, Amount: 20000, Quantity: 3},
+		},
+	}
+	items.EXPECT().Get(ctx, log, []uint64{1, 2}).Return(map[uint64]item.Item{
+		1: {ID: 1, Name: "premium", Price: 100000},
+		2: {ID: 2, Name: "calltracking", Price: 20000},
+	}, nil).Times(1)
+	repo.EXPECT().Save(ctx, log, expected).Return(nil).Times(1)
+
+	Usecase := New(repo, items, NoDiscount{}, recorder)
 	err := Usecase.Save(ctx, log, in)
 	require.NoError(t, err)
+	require.Equal(t, uint64(160000), in.OriginalAmount)
+	require.Equal(t, map[string]int64{metrics.SaveOrderSuccess: 1, metrics.SaveOrderCount: 1}, recorder.Counters())
+}
+
+func TestSaveUnknownItems(t *testing.T) {
+	recorder := metrics.NewRecorder()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockOrderRepo(ctl)
+	items := itemMock.NewMockItemRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	in := &order.Order{
+		Items: []order.Item{{ID: 1}, {ID: 7}, {ID: 7}, {ID: 9}},
+	}
+	items.EXPECT().Get(ctx, log, []uint64{1, 7, 7, 9}).Return(map[uint64]item.Item{
+		1: {ID: 1, Name: "premium", Price: 100000},
+	}, nil).Times(1)
+
+	Usecase := New(repo, items, NoDiscount{}, recorder)
+	err := Usecase.Save(ctx, log, in)
+
+	var unknownErr *UnknownItemsError
+	require.ErrorAs(t, err, &unknownErr)
+	require.Equal(t, []uint64{7, 9}, unknownErr.IDs)
+	require.Equal(t, map[string]int64{metrics.SaveOrderError: 1, metrics.SaveOrderCount: 1}, recorder.Counters())
 }
@@ -0,0 +1,82 @@
+package promo_ucase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	order_ucase "github.com/ansakharov/lets_test/internal/app/usecase/order"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	promoRepo "github.com/ansakharov/lets_test/internal/pkg/repository/promo"
+	"github.com/sirupsen/logrus"
+)
+
+// Promo code rejection reasons.
+var (
+	ErrPromoCodeNotFound      = fmt.Errorf("%w: promo code not found", order_ucase.ErrDiscountRejected)
+	ErrPromoCodeInactive      = fmt.Errorf("%w: promo code is not active", order_ucase.ErrDiscountRejected)
+	ErrPromoCodeExhausted     = fmt.Errorf("%w: promo code usage limit reached", order_ucase.ErrDiscountRejected)
+	ErrPromoCodeNotApplicable = fmt.Errorf("%w: promo code doesn't apply to order items", order_ucase.ErrDiscountRejected)
+)
+
+// Usecase gives discounts by promo codes.
+type Usecase struct {
+	repo promoRepo.PromoRepo
+	now  func() time.Time
+}
+
+// New gives Usecase.
+func New(promoRepo promoRepo.PromoRepo) *Usecase {
+	return &Usecase{
+		repo: promoRepo,
+		now:  time.Now,
+	}
+}
+
+// Apply sets discounts of order items according to order promo code.
+func (uc *Usecase) Apply(ctx context.Context, log logrus.FieldLogger, ord *order.Order) error {
+	for idx := range ord.Items {
+		ord.Items[idx].DiscountedAmount = 0
+	}
+	if ord.PromoCode == "" {
+		return nil
+	}
+
+	code, err := uc.repo.GetByCode(ctx, log, ord.PromoCode)
+	if errors.Is(err, promoRepo.ErrPromoCodeNotFound) {
+		return ErrPromoCodeNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("err from promo_repository: %w", err)
+	}
+
+	if !code.ActiveAt(uc.now()) {
+		return ErrPromoCodeInactive
+	}
+
+	if code.MaxUses != 0 || code.MaxUsesPerUser != 0 {
+		total, byUser, err := uc.repo.Usages(ctx, log, code.ID, ord.UserID)
+		if err != nil {
+			return fmt.Errorf("err from promo_repository: %w", err)
+		}
+		if (code.MaxUses != 0 && total >= code.MaxUses) ||
+			(code.MaxUsesPerUser != 0 && byUser >= code.MaxUsesPerUser) {
+			return ErrPromoCodeExhausted
+		}
+	}
+
+	applied := false
+	for idx, item := range ord.Items {
+		if !code.AppliesTo(item.ID) {
+			continue
+		}
+		ord.Items[idx].DiscountedAmount = code.Discount(item.Amount)
+		applied = true
+	}
+	if !applied {
+		return ErrPromoCodeNotApplicable
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package promo_ucase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/promo"
+	fake_promo "github.com/ansakharov/lets_test/internal/pkg/repository/promo/fake_promo_repo"
+	repoMock "github.com/ansakharov/lets_test/internal/pkg/repository/promo/mocks"
+	log "github.com/ansakharov/lets_test/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	log := log.New()
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := fake_promo.New()
+	for _, code := range []promo.Code{
+		{Code: "SALE15", Kind: promo.Percent, Value: 15},
+		{Code: "MINUS5K", Kind: promo.Fixed, Value: 5000, ItemIDs: []uint64{2}},
+		{Code: "OLD", Kind: promo.Percent, Value: 50, ValidTo: now.Add(-time.Hour)},
+		{Code: "ONCE", Kind: promo.Percent, Value: 50, MaxUsesPerUser: 1},
+		{Code: "LIMITED", Kind: promo.Percent, Value: 50, MaxUses: 1},
+	} {
+		require.NoError(t, repo.Save(ctx, log, &code))
+	}
+	// ONCE was used by user 1, LIMITED by user 2.
+	repo.Use(ctx, log, 4, 1)
+	repo.Use(ctx, log, 5, 2)
+
+	cases := []struct {
+		name         string
+		code         string
+		expErr       error
+		expDiscounts []uint64
+	}{
+		{name: "no_code", code: "", expDiscounts: []uint64{0, 0}},
+		{name: "percent", code: "SALE15", expDiscounts: []uint64{15000, 3000}},
+		{name: "fixed_single_item", code: "MINUS5K", expDiscounts: []uint64{0, 5000}},
+		{name: "not_found", code: "NOPE", expErr: ErrPromoCodeNotFound},
+		{name: "expired", code: "OLD", expErr: ErrPromoCodeInactive},
+		{name: "used_by_user", code: "ONCE", expErr: ErrPromoCodeExhausted},
+		{name: "used_globally", code: "LIMITED", expErr: ErrPromoCodeExhausted},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			ord := &order.Order{
+				UserID:    1,
+				PromoCode: tCase.code,
+				Items: []order.Item{
+					{ID: 1, Amount: 100000, DiscountedAmount: 1},
+					{ID: 2, Amount: 20000, DiscountedAmount: 1},
+				},
+			}
+
+			Usecase := New(repo)
+			Usecase.now = func() time.Time { return now }
+			err := Usecase.Apply(ctx, log, ord)
+			if tCase.expErr != nil {
+				require.ErrorIs(t, err, tCase.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tCase.expDiscounts, []uint64{
+				ord.Items[0].DiscountedAmount,
+				ord.Items[1].DiscountedAmount,
+			})
+		})
+	}
+}
+
+func TestApplyNotApplicable(t *testing.T) {
+	ctx := context.Background()
+	log := log.New()
+
+	repo := fake_promo.New()
+	code := promo.Code{Code: "LIMITONLY", Kind: promo.Percent, Value: 10, ItemIDs: []uint64{4}}
+	require.NoError(t, repo.Save(ctx, log, &code))
+
+	ord := &order.Order{
+		UserID:    1,
+		PromoCode: "LIMITONLY",
+		Items:     []order.Item{{ID: 1, Amount: 100000}},
+	}
+	err := New(repo).Apply(ctx, log, ord)
+	require.ErrorIs(t, err, ErrPromoCodeNotApplicable)
+}
+
+func TestApplyRepoError(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := repoMock.NewMockPromoRepo(ctl)
+
+	ctx := context.Background()
+	log := log.New()
+	repo.EXPECT().GetByCode(ctx, log, "SALE15").Return(promo.Code{}, errors.New("db is down")).Times(1)
+
+	ord := &order.Order{PromoCode: "SALE15"}
+	err := New(repo).Apply(ctx, log, ord)
+	require.EqualError(t, err, "err from promo_repository: db is down")
+}
+
+func TestApplyInvalidCode(t *testing.T) {
+	ctx := context.Background()
+	log := log.New()
+
+	repo := fake_promo.New()
+	code := promo.Code{Code: "ZERO", Kind: promo.Percent}
+	err := repo.Save(ctx, log, &code)
+	require.Equal(t, apperr.Validation, apperr.KindOf(err))
+
+	ord := &order.Order{UserID: 1, PromoCode: "ZERO", Items: []order.Item{{ID: 1, Amount: 100000}}}
+	err = New(repo).Apply(ctx, log, ord)
+	require.ErrorIs(t, err, ErrPromoCodeNotFound)
+}
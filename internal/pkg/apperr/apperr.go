@@ -0,0 +1,121 @@
+package apperr
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// Kind classifies errors, so transport can report them without knowing every error.
+type Kind uint8
+
+const (
+	// Internal is unexpected failure, its text isn't shown to clients.
+	Internal Kind = iota
+	// Validation is malformed or invalid request.
+	Validation
+	// NotFound is missing entity.
+	NotFound
+	// Conflict is request conflicting with current state of entity.
+	Conflict
+	// Unprocessable is valid request which can't be fulfilled, e.g. with unknown items.
+	Unprocessable
+	// Unavailable is temporary failure of dependency, request can be retried.
+	Unavailable
+)
+
+var kindNames = map[Kind]string{
+	Internal:      "internal",
+	Validation:    "validation",
+	NotFound:      "not_found",
+	Conflict:      "conflict",
+	Unprocessable: "unprocessable",
+	Unavailable:   "unavailable",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Error is error of known kind.
+type Error struct {
+	kind    Kind
+	message string
+	// errs are failures listed in details, e.g. all validation errors of request.
+	errs  []error
+	cause error
+}
+
+// New gives error of kind.
+func New(kind Kind, message string) *Error {
+	return &Error{kind: kind, message: message}
+}
+
+// Wrap gives error of kind caused by err, text of err is listed in details.
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{kind: kind, message: message, errs: []error{err}, cause: err}
+}
+
+// NewValidation gives Validation error listing all errs, nil if there are none.
+func NewValidation(message string, errs ...error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &Error{kind: Validation, message: message, errs: errs}
+}
+
+func (e *Error) Error() string {
+	if len(e.errs) == 0 {
+		return e.message
+	}
+	return e.message + ": " + strings.Join(e.Details(), "; ")
+}
+
+// Unwrap gives cause of Wrap.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether any of listed errors matches target,
+// so callers can check single validation failure with errors.Is.
+func (e *Error) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kind of error.
+func (e *Error) Kind() Kind {
+	return e.kind
+}
+
+// Message gives error text without details.
+func (e *Error) Message() string {
+	return e.message
+}
+
+// Details gives texts of listed errors.
+func (e *Error) Details() []string {
+	details := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		details = append(details, err.Error())
+	}
+	return details
+}
+
+// KindOf gives kind of the first error in chain having Kind method.
+// Network errors are Unavailable, unknown errors are Internal.
+func KindOf(err error) Kind {
+	var kinded interface{ Kind() Kind }
+	if errors.As(err, &kinded) {
+		return kinded.Kind()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Unavailable
+	}
+	return Internal
+}
@@ -0,0 +1,55 @@
+package apperr_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidation(t *testing.T) {
+	require.NoError(t, apperr.NewValidation("bad request"))
+
+	errName := errors.New("name can't be empty")
+	errPrice := errors.New("invalid price")
+	err := apperr.NewValidation("bad request", errName, errPrice)
+
+	require.EqualError(t, err, "bad request: name can't be empty; invalid price")
+	require.ErrorIs(t, err, errName)
+	require.ErrorIs(t, err, errPrice)
+	require.NotErrorIs(t, err, errors.New("invalid price"))
+	require.Equal(t, apperr.Validation, apperr.KindOf(err))
+
+	var appErr *apperr.Error
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, "bad request", appErr.Message())
+	require.Equal(t, []string{"name can't be empty", "invalid price"}, appErr.Details())
+}
+
+func TestKindOf(t *testing.T) {
+	errNotFound := apperr.New(apperr.NotFound, "order not found")
+	cause := errors.New("unexpected EOF")
+
+	cases := []struct {
+		name     string
+		err      error
+		expected apperr.Kind
+	}{
+		{name: "typed", err: errNotFound, expected: apperr.NotFound},
+		{name: "wrapped", err: fmt.Errorf("can't get order: %w", errNotFound), expected: apperr.NotFound},
+		{name: "wrap", err: apperr.Wrap(apperr.Validation, "bad json", cause), expected: apperr.Validation},
+		{name: "network", err: fmt.Errorf("can't select orders: %w", &net.OpError{Op: "dial", Err: cause}), expected: apperr.Unavailable},
+		{name: "unknown", err: cause, expected: apperr.Internal},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			require.Equal(t, tCase.expected, apperr.KindOf(tCase.err))
+		})
+	}
+
+	require.ErrorIs(t, apperr.Wrap(apperr.Validation, "bad json", cause), cause)
+}
@@ -0,0 +1,20 @@
+package idempotency
+
+import "time"
+
+// Record is stored result of request made with idempotency key.
+type Record struct {
+	Key         string
+	RequestHash string
+	// Zero StatusCode means request is still in progress.
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	OrderID     uint64
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether request result is stored.
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,8 @@
+package item
+
+// Item represents catalog item which can be ordered.
+type Item struct {
+	ID    uint64
+	Name  string
+	Price uint64
+}
@@ -0,0 +1,61 @@
+package order
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor returned when cursor can't be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to the last order of a page.
+// Orders are listed from newest to oldest by (CreatedAt, ID).
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint64
+}
+
+// CursorOf gives cursor pointing to order.
+func CursorOf(ord Order) Cursor {
+	return Cursor{CreatedAt: ord.CreatedAt, ID: ord.ID}
+}
+
+// After reports whether order goes after cursor in listing.
+func (c Cursor) After(ord Order) bool {
+	if ord.CreatedAt.Equal(c.CreatedAt) {
+		return ord.ID < c.ID
+	}
+	return ord.CreatedAt.Before(c.CreatedAt)
+}
+
+// Encode gives opaque representation of cursor for clients.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses cursor made by Encode.
+func DecodeCursor(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	ID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || ID == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: ID}, nil
+}
@@ -0,0 +1,33 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorEncode(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2022, 5, 1, 12, 0, 0, 123000, time.UTC), ID: 42}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	require.Equal(t, cursor, decoded)
+}
+
+func TestDecodeCursorError(t *testing.T) {
+	for _, value := range []string{"", "!!!", "MTIz", "YWJjOjE", "MTIzOjA"} {
+		_, err := DecodeCursor(value)
+		require.ErrorIs(t, err, ErrInvalidCursor, value)
+	}
+}
+
+func TestCursorAfter(t *testing.T) {
+	createdAt := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	cursor := Cursor{CreatedAt: createdAt, ID: 5}
+
+	require.True(t, cursor.After(Order{ID: 4, CreatedAt: createdAt}))
+	require.True(t, cursor.After(Order{ID: 9, CreatedAt: createdAt.Add(-time.Second)}))
+	require.False(t, cursor.After(Order{ID: 5, CreatedAt: createdAt}))
+	require.False(t, cursor.After(Order{ID: 1, CreatedAt: createdAt.Add(time.Second)}))
+}
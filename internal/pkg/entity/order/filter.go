@@ -0,0 +1,56 @@
+package order
+
+import "time"
+
+// Filter selects orders, zero fields are not used.
+type Filter struct {
+	IDs         []uint64
+	UserID      uint64
+	Status      Status
+	PaymentType PaymentType
+	// CreatedFrom is inclusive, CreatedTo is exclusive.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// Empty reports whether filter has no conditions.
+func (f Filter) Empty() bool {
+	return len(f.IDs) == 0 &&
+		f.UserID == 0 &&
+		f.Status == UnknownStatus &&
+		f.PaymentType == UnknownType &&
+		f.CreatedFrom.IsZero() &&
+		f.CreatedTo.IsZero()
+}
+
+// Match reports whether order satisfies filter.
+func (f Filter) Match(ord Order) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, ID := range f.IDs {
+			if ID == ord.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.UserID != 0 && f.UserID != ord.UserID {
+		return false
+	}
+	if f.Status != UnknownStatus && f.Status != ord.Status {
+		return false
+	}
+	if f.PaymentType != UnknownType && f.PaymentType != ord.PaymentType {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && ord.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !ord.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+	return true
+}
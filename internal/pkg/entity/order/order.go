@@ -1,5 +1,7 @@
 package order
 
+import "time"
+
 // Order represents clients order.
 type Order struct {
 	ID               uint64
@@ -8,6 +10,8 @@ type Order struct {
 	PaymentType      PaymentType
 	OriginalAmount   uint64
 	DiscountedAmount uint64
+	PromoCode        string
+	CreatedAt        time.Time
 	Items            []Item
 }
 
@@ -21,6 +25,45 @@ const (
 	CanceledStatus
 )
 
+// transitions lists statuses order can be moved to from the given one.
+var transitions = map[Status][]Status{
+	CreatedStatus:   {ProcessedStatus, CanceledStatus},
+	ProcessedStatus: {CanceledStatus},
+}
+
+// CanTransitionTo reports whether order in status s may be moved to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+var statusNames = map[Status]string{
+	CreatedStatus:   "created",
+	ProcessedStatus: "processed",
+	CanceledStatus:  "canceled",
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseStatus gives status by its name.
+func ParseStatus(name string) (Status, bool) {
+	for s, n := range statusNames {
+		if n == name {
+			return s, true
+		}
+	}
+	return UnknownStatus, false
+}
+
 // Way of payment
 type PaymentType uint8
 
@@ -30,9 +73,35 @@ const (
 	Wallet
 )
 
+var paymentTypeNames = map[PaymentType]string{
+	Card:   "card",
+	Wallet: "wallet",
+}
+
+func (p PaymentType) String() string {
+	if name, ok := paymentTypeNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParsePaymentType gives payment type by its name.
+func ParsePaymentType(name string) (PaymentType, bool) {
+	for p, n := range paymentTypeNames {
+		if n == name {
+			return p, true
+		}
+	}
+	return UnknownType, false
+}
+
+// Item is a catalog item line in order.
+// Amount is catalog price of single unit, DiscountedAmount is discount
+// subtracted from it. Line totals are multiplied by Quantity.
 type Item struct {
 	OrderID          uint64 `db:"order_id"`
 	ID               uint64 `db:"item_id"`
 	Amount           uint64 `db:"amount"`
 	DiscountedAmount uint64 `db:"discounted_amount"`
+	Quantity         uint64 `db:"quantity"`
 }
@@ -0,0 +1,28 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{CreatedStatus, ProcessedStatus, true},
+		{CreatedStatus, CanceledStatus, true},
+		{ProcessedStatus, CanceledStatus, true},
+		{ProcessedStatus, CreatedStatus, false},
+		{CanceledStatus, ProcessedStatus, false},
+		{CanceledStatus, CreatedStatus, false},
+		{CreatedStatus, CreatedStatus, false},
+		{UnknownStatus, ProcessedStatus, false},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.from.String()+"_to_"+tCase.to.String(), func(t *testing.T) {
+			require.Equal(t, tCase.allowed, tCase.from.CanTransitionTo(tCase.to))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package promo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+)
+
+// Code represents promo code giving discount on order items.
+type Code struct {
+	ID    uint64
+	Code  string
+	Kind  Kind
+	Value uint64
+	// Zero ValidFrom or ValidTo means code is not limited from that side.
+	ValidFrom time.Time
+	ValidTo   time.Time
+	// Zero limit means code can be used unlimited times.
+	MaxUses        uint64
+	MaxUsesPerUser uint64
+	// Empty ItemIDs means code applies to any item.
+	ItemIDs []uint64
+}
+
+// Kind of discount.
+type Kind uint8
+
+const (
+	UnknownKind Kind = iota
+	// Percent discount, Value is percent of item price.
+	Percent
+	// Fixed discount, Value is amount subtracted from item price.
+	Fixed
+)
+
+// Validate checks that Value makes sense for Kind:
+// percent must be in (0, 100], fixed amount must be positive.
+func (c Code) Validate() error {
+	var errs []error
+	switch c.Kind {
+	case Percent:
+		if c.Value == 0 || c.Value > 100 {
+			errs = append(errs, errors.New("percent value must be in (0, 100]"))
+		}
+	case Fixed:
+		if c.Value == 0 {
+			errs = append(errs, errors.New("fixed value must be positive"))
+		}
+	default:
+		errs = append(errs, errors.New("unknown kind"))
+	}
+
+	return apperr.NewValidation("invalid promo code", errs...)
+}
+
+// ActiveAt reports whether code is valid at moment t.
+func (c Code) ActiveAt(t time.Time) bool {
+	if !c.ValidFrom.IsZero() && t.Before(c.ValidFrom) {
+		return false
+	}
+	if !c.ValidTo.IsZero() && !t.Before(c.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// AppliesTo reports whether code gives discount on item.
+func (c Code) AppliesTo(itemID uint64) bool {
+	if len(c.ItemIDs) == 0 {
+		return true
+	}
+	for _, ID := range c.ItemIDs {
+		if ID == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount for item with given price, never exceeds price.
+func (c Code) Discount(price uint64) uint64 {
+	var discount uint64
+	switch c.Kind {
+	case Percent:
+		discount = price * c.Value / 100
+	case Fixed:
+		discount = c.Value
+	}
+	if discount > price {
+		return price
+	}
+	return discount
+}
@@ -0,0 +1,82 @@
+package promo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		code   Code
+		expErr string
+	}{
+		{name: "percent", code: Code{Kind: Percent, Value: 15}},
+		{name: "percent_100", code: Code{Kind: Percent, Value: 100}},
+		{name: "percent_zero", code: Code{Kind: Percent}, expErr: "invalid promo code: percent value must be in (0, 100]"},
+		{name: "percent_over_100", code: Code{Kind: Percent, Value: 150}, expErr: "invalid promo code: percent value must be in (0, 100]"},
+		{name: "fixed", code: Code{Kind: Fixed, Value: 5000}},
+		{name: "fixed_zero", code: Code{Kind: Fixed}, expErr: "invalid promo code: fixed value must be positive"},
+		{name: "unknown_kind", code: Code{Value: 5000}, expErr: "invalid promo code: unknown kind"},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			err := tCase.code.Validate()
+			if tCase.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tCase.expErr)
+			require.Equal(t, apperr.Validation, apperr.KindOf(err))
+		})
+	}
+}
+
+func TestActiveAt(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		code   Code
+		active bool
+	}{
+		{name: "unlimited", code: Code{}, active: true},
+		{name: "started", code: Code{ValidFrom: now.Add(-time.Hour)}, active: true},
+		{name: "not_started", code: Code{ValidFrom: now.Add(time.Hour)}, active: false},
+		{name: "not_expired", code: Code{ValidTo: now.Add(time.Hour)}, active: true},
+		{name: "expired", code: Code{ValidTo: now}, active: false},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			require.Equal(t, tCase.active, tCase.code.ActiveAt(now))
+		})
+	}
+}
+
+func TestAppliesTo(t *testing.T) {
+	require.True(t, Code{}.AppliesTo(1))
+	require.True(t, Code{ItemIDs: []uint64{1, 2}}.AppliesTo(2))
+	require.False(t, Code{ItemIDs: []uint64{1, 2}}.AppliesTo(3))
+}
+
+func TestDiscount(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     Code
+		price    uint64
+		discount uint64
+	}{
+		{name: "percent", code: Code{Kind: Percent, Value: 15}, price: 20000, discount: 3000},
+		{name: "percent_over_100", code: Code{Kind: Percent, Value: 150}, price: 20000, discount: 20000},
+		{name: "fixed", code: Code{Kind: Fixed, Value: 5000}, price: 20000, discount: 5000},
+		{name: "fixed_over_price", code: Code{Kind: Fixed, Value: 50000}, price: 20000, discount: 20000},
+		{name: "unknown_kind", code: Code{Value: 5000}, price: 20000, discount: 0},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			require.Equal(t, tCase.discount, tCase.code.Discount(tCase.price))
+		})
+	}
+}
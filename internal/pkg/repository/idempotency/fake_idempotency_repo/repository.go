@@ -0,0 +1,65 @@
+package fake_idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/idempotency"
+	keyRepo "github.com/ansakharov/lets_test/internal/pkg/repository/idempotency"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository struct {
+	records map[string]idempotency.Record
+}
+
+// New instance of repository.
+func New() *Repository {
+	return &Repository{
+		records: make(map[string]idempotency.Record),
+	}
+}
+
+// Reserve saves in progress record for key.
+// If unexpired record for key exists, it's returned with false.
+func (r *Repository) Reserve(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) (idempotency.Record, bool, error) {
+	existing, ok := r.records[rec.Key]
+	if ok && time.Now().Before(existing.ExpiresAt) {
+		return existing, false, nil
+	}
+	r.records[rec.Key] = rec
+
+	return rec, true, nil
+}
+
+// Complete stores request result for key reserved by request with rec.RequestHash
+// and prolongs it till rec.ExpiresAt. ErrNotReserved returned if reservation is lost.
+func (r *Repository) Complete(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) error {
+	stored, ok := r.records[rec.Key]
+	if !ok || !reservedBy(stored, rec.RequestHash) {
+		return keyRepo.ErrNotReserved
+	}
+	stored.StatusCode = rec.StatusCode
+	stored.ContentType = rec.ContentType
+	stored.Body = rec.Body
+	stored.OrderID = rec.OrderID
+	stored.ExpiresAt = rec.ExpiresAt
+	r.records[rec.Key] = stored
+
+	return nil
+}
+
+// Release removes key reserved by request with requestHash, so request can be retried.
+// Key taken by another request or having stored result is kept.
+func (r *Repository) Release(ctx context.Context, log logrus.FieldLogger, key, requestHash string) error {
+	if stored, ok := r.records[key]; ok && reservedBy(stored, requestHash) {
+		delete(r.records, key)
+	}
+
+	return nil
+}
+
+// reservedBy reports whether rec is in progress reservation of request with requestHash.
+func reservedBy(rec idempotency.Record, requestHash string) bool {
+	return rec.RequestHash == requestHash && !rec.Completed()
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/pkg/repository/idempotency/repository.go
+
+// Package mock_idempotency is a generated GoMock package.
+package mock_idempotency
+
+import (
+	context "context"
+	reflect "reflect"
+
+	idempotency "github.com/ansakharov/lets_test/internal/pkg/entity/idempotency"
+	gomock "github.com/golang/mock/gomock"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// MockKeyRepo is a mock of KeyRepo interface.
+type MockKeyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyRepoMockRecorder
+}
+
+// MockKeyRepoMockRecorder is the mock recorder for MockKeyRepo.
+type MockKeyRepoMockRecorder struct {
+	mock *MockKeyRepo
+}
+
+// NewMockKeyRepo creates a new mock instance.
+func NewMockKeyRepo(ctrl *gomock.Controller) *MockKeyRepo {
+	mock := &MockKeyRepo{ctrl: ctrl}
+	mock.recorder = &MockKeyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyRepo) EXPECT() *MockKeyRepoMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockKeyRepo) Complete(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, log, rec)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockKeyRepoMockRecorder) Complete(ctx, log, rec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockKeyRepo)(nil).Complete), ctx, log, rec)
+}
+
+// Release mocks base method.
+func (m *MockKeyRepo) Release(ctx context.Context, log logrus.FieldLogger, key, requestHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, log, key, requestHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockKeyRepoMockRecorder) Release(ctx, log, key, requestHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockKeyRepo)(nil).Release), ctx, log, key, requestHash)
+}
+
+// Reserve mocks base method.
+func (m *MockKeyRepo) Reserve(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) (idempotency.Record, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, log, rec)
+	ret0, _ := ret[0].(idempotency.Record)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockKeyRepoMockRecorder) Reserve(ctx, log, rec interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockKeyRepo)(nil).Reserve), ctx, log, rec)
+}
@@ -0,0 +1,156 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/idempotency"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// tables
+	idempotencyKeysTable = "idempotency_keys"
+)
+
+// ErrNotReserved returned when key isn't reserved by request anymore:
+// its lease expired and key was taken by retry, or result is already stored.
+var ErrNotReserved = errors.New("idempotency key is not reserved by request")
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+type KeyRepo interface {
+	Reserve(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) (idempotency.Record, bool, error)
+	Complete(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) error
+	Release(ctx context.Context, log logrus.FieldLogger, key, requestHash string) error
+}
+
+// New instance of repository.
+func New(pool *pgxpool.Pool) *Repository {
+	return &Repository{db: pool}
+}
+
+// Reserve saves in progress record for key.
+// If unexpired record for key exists, it's returned with false.
+func (r *Repository) Reserve(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) (idempotency.Record, bool, error) {
+	// expired key can be reused.
+	query, args, err := sq.
+		Delete(idempotencyKeysTable).
+		Where(sq.Eq{"key": rec.Key}).
+		Where("expires_at <= now()").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("can't build sql: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("can't delete expired key: %w", err)
+	}
+
+	query, args, err = sq.
+		Insert(idempotencyKeysTable).
+		Columns("key", "request_hash", "expires_at").
+		Values(rec.Key, rec.RequestHash, rec.ExpiresAt).
+		Suffix("ON CONFLICT (key) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("can't build sql: %w", err)
+	}
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("can't insert key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return rec, true, nil
+	}
+
+	// key is already taken.
+	query, args, err = sq.
+		Select(
+			"key",
+			"request_hash",
+			"status_code",
+			"content_type",
+			"body",
+			"order_id",
+			"expires_at",
+		).
+		From(idempotencyKeysTable).
+		Where(sq.Eq{"key": rec.Key}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("can't build sql: %w", err)
+	}
+
+	existing := idempotency.Record{}
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&existing.Key,
+		&existing.RequestHash,
+		&existing.StatusCode,
+		&existing.ContentType,
+		&existing.Body,
+		&existing.OrderID,
+		&existing.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return idempotency.Record{}, false, fmt.Errorf("key %q released concurrently", rec.Key)
+	}
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("can't select key: %w", err)
+	}
+
+	return existing, false, nil
+}
+
+// Complete stores request result for key reserved by request with rec.RequestHash
+// and prolongs it till rec.ExpiresAt. ErrNotReserved returned if reservation is lost.
+func (r *Repository) Complete(ctx context.Context, log logrus.FieldLogger, rec idempotency.Record) error {
+	query, args, err := sq.
+		Update(idempotencyKeysTable).
+		Set("status_code", rec.StatusCode).
+		Set("content_type", rec.ContentType).
+		Set("body", rec.Body).
+		Set("order_id", rec.OrderID).
+		Set("expires_at", rec.ExpiresAt).
+		Where(sq.Eq{"key": rec.Key, "request_hash": rec.RequestHash, "status_code": 0}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("can't update key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotReserved
+	}
+
+	return nil
+}
+
+// Release removes key reserved by request with requestHash, so request can be retried.
+// Key taken by another request or having stored result is kept.
+func (r *Repository) Release(ctx context.Context, log logrus.FieldLogger, key, requestHash string) error {
+	query, args, err := sq.
+		Delete(idempotencyKeysTable).
+		Where(sq.Eq{"key": key, "request_hash": requestHash, "status_code": 0}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("can't delete key: %w", err)
+	}
+
+	return nil
+}
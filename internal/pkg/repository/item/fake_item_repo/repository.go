@@ -0,0 +1,67 @@
+package fake_item
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	itemRepo "github.com/ansakharov/lets_test/internal/pkg/repository/item"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository struct {
+	items  map[uint64]item.Item
+	currID uint64
+}
+
+// New instance of repository.
+func New() *Repository {
+	return &Repository{
+		items:  make(map[uint64]item.Item),
+		currID: 1,
+	}
+}
+
+// List returns all items ordered by id.
+func (r *Repository) List(ctx context.Context, log logrus.FieldLogger) ([]item.Item, error) {
+	result := make([]item.Item, 0, len(r.items))
+	for _, it := range r.items {
+		result = append(result, it)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+// Get returns map of items.
+func (r *Repository) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]item.Item, error) {
+	result := make(map[uint64]item.Item)
+
+	for _, ID := range IDs {
+		it, ok := r.items[ID]
+		if ok {
+			result[ID] = it
+		}
+	}
+
+	return result, nil
+}
+
+// Save new item.
+func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	item.ID = r.currID
+	r.items[r.currID] = *item
+	r.currID++
+
+	return nil
+}
+
+// Update name and price of existing item.
+func (r *Repository) Update(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	if _, ok := r.items[item.ID]; !ok {
+		return itemRepo.ErrItemNotFound
+	}
+	r.items[item.ID] = *item
+
+	return nil
+}
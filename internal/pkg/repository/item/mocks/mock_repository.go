@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/pkg/repository/item/repository.go
+
+// Package mock_item is a generated GoMock package.
+package mock_item
+
+import (
+	context "context"
+	reflect "reflect"
+
+	item "github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	gomock "github.com/golang/mock/gomock"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// MockItemRepo is a mock of ItemRepo interface.
+type MockItemRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockItemRepoMockRecorder
+}
+
+// MockItemRepoMockRecorder is the mock recorder for MockItemRepo.
+type MockItemRepoMockRecorder struct {
+	mock *MockItemRepo
+}
+
+// NewMockItemRepo creates a new mock instance.
+func NewMockItemRepo(ctrl *gomock.Controller) *MockItemRepo {
+	mock := &MockItemRepo{ctrl: ctrl}
+	mock.recorder = &MockItemRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockItemRepo) EXPECT() *MockItemRepoMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockItemRepo) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]item.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, log, IDs)
+	ret0, _ := ret[0].(map[uint64]item.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockItemRepoMockRecorder) Get(ctx, log, IDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockItemRepo)(nil).Get), ctx, log, IDs)
+}
+
+// List mocks base method.
+func (m *MockItemRepo) List(ctx context.Context, log logrus.FieldLogger) ([]item.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, log)
+	ret0, _ := ret[0].([]item.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockItemRepoMockRecorder) List(ctx, log interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockItemRepo)(nil).List), ctx, log)
+}
+
+// Save mocks base method.
+func (m *MockItemRepo) Save(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, log, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockItemRepoMockRecorder) Save(ctx, log, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockItemRepo)(nil).Save), ctx, log, item)
+}
+
+// Update mocks base method.
+func (m *MockItemRepo) Update(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, log, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockItemRepoMockRecorder) Update(ctx, log, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockItemRepo)(nil).Update), ctx, log, item)
+}
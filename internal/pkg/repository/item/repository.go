@@ -0,0 +1,155 @@
+package item
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/item"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// tables
+	itemsTable = "items"
+)
+
+// ErrItemNotFound returned when item doesn't exist.
+var ErrItemNotFound = apperr.New(apperr.NotFound, "item not found")
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+type ItemRepo interface {
+	List(ctx context.Context, log logrus.FieldLogger) ([]item.Item, error)
+	Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]item.Item, error)
+	Save(ctx context.Context, log logrus.FieldLogger, item *item.Item) error
+	Update(ctx context.Context, log logrus.FieldLogger, item *item.Item) error
+}
+
+// New instance of repository.
+func New(pool *pgxpool.Pool) *Repository {
+	return &Repository{db: pool}
+}
+
+// List returns all items ordered by id.
+func (r *Repository) List(ctx context.Context, log logrus.FieldLogger) ([]item.Item, error) {
+	query, args, err := sq.
+		Select("id", "name", "price").
+		From(itemsTable).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("can't build query: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't select items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []item.Item{}
+	for rows.Next() {
+		it, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Get returns map of items.
+func (r *Repository) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]item.Item, error) {
+	query, args, err := sq.
+		Select("id", "name", "price").
+		From(itemsTable).
+		Where(sq.Eq{"id": IDs}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("can't build query: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't select items: %w", err)
+	}
+	defer rows.Close()
+
+	itemsMap := make(map[uint64]item.Item, len(IDs))
+	for rows.Next() {
+		it, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		itemsMap[it.ID] = it
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select items: %w", err)
+	}
+
+	return itemsMap, nil
+}
+
+// Save new item to DB.
+func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	query, args, err := sq.
+		Insert(itemsTable).
+		Columns("name", "price").
+		Values(item.Name, item.Price).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&item.ID); err != nil {
+		return fmt.Errorf("can't insert item: %w", err)
+	}
+
+	return nil
+}
+
+// Update name and price of existing item.
+func (r *Repository) Update(ctx context.Context, log logrus.FieldLogger, item *item.Item) error {
+	query, args, err := sq.
+		Update(itemsTable).
+		Set("name", item.Name).
+		Set("price", item.Price).
+		Where(sq.Eq{"id": item.ID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("can't update item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrItemNotFound
+	}
+
+	return nil
+}
+
+func scanItem(rows pgx.Rows) (item.Item, error) {
+	it := item.Item{}
+	if err := rows.Scan(&it.ID, &it.Name, &it.Price); err != nil {
+		return item.Item{}, fmt.Errorf("can't scan item: %w", err)
+	}
+	return it, nil
+}
@@ -2,14 +2,20 @@ package fake_order
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	orderRepo "github.com/ansakharov/lets_test/internal/pkg/repository/order"
 	"github.com/sirupsen/logrus"
 )
 
 type Repository struct {
 	orders map[uint64]*order.Order
 	currID uint64
+
+	// Now gives creation time of saved orders.
+	Now func() time.Time
 }
 
 // New instance of repository.
@@ -17,32 +23,75 @@ func New() *Repository {
 	return &Repository{
 		orders: make(map[uint64]*order.Order),
 		currID: 1,
+		Now:    time.Now,
 	}
 }
 
 // Save new order to DB.
 func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, order *order.Order) error {
 	order.ID = r.currID
+	order.CreatedAt = r.Now()
 	for idx, item := range order.Items {
 		item.OrderID = r.currID
 		order.Items[idx] = item
 	}
-	r.orders[r.currID] = order
+	stored := *order
+	stored.Items = append(stored.Items[:0:0], order.Items...)
+	r.orders[r.currID] = &stored
 	r.currID++
 
 	return nil
 }
 
-// Get returns map of orders.
-func (r *Repository) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]order.Order, error) {
+// Get returns map of orders matching filter.
+func (r *Repository) Get(ctx context.Context, log logrus.FieldLogger, filter order.Filter) (map[uint64]order.Order, error) {
 	result := make(map[uint64]order.Order)
 
-	for _, ID := range IDs {
-		order, ok := r.orders[ID]
-		if ok {
+	for ID, order := range r.orders {
+		if filter.Match(*order) {
 			result[ID] = *order
 		}
 	}
 
 	return result, nil
 }
+
+// List returns page of orders matching filter from newest to oldest.
+func (r *Repository) List(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	filter order.Filter,
+	after *order.Cursor,
+	limit uint64,
+) ([]order.Order, error) {
+	result := make([]order.Order, 0, len(r.orders))
+	for _, order := range r.orders {
+		if !filter.Match(*order) {
+			continue
+		}
+		if after != nil && !after.After(*order) {
+			continue
+		}
+		result = append(result, *order)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return order.CursorOf(result[i]).After(result[j])
+	})
+	if uint64(len(result)) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// UpdateStatus moves order from one status to another.
+func (r *Repository) UpdateStatus(ctx context.Context, log logrus.FieldLogger, ID uint64, from, to order.Status) error {
+	order, ok := r.orders[ID]
+	if !ok || order.Status != from {
+		return orderRepo.ErrStatusMismatch
+	}
+	order.Status = to
+
+	return nil
+}
@@ -37,18 +37,33 @@ func (m *MockOrderRepo) EXPECT() *MockOrderRepoMockRecorder {
 }
 
 // Get mocks base method.
-func (m *MockOrderRepo) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]order.Order, error) {
+func (m *MockOrderRepo) Get(ctx context.Context, log logrus.FieldLogger, filter order.Filter) (map[uint64]order.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, log, IDs)
+	ret := m.ctrl.Call(m, "Get", ctx, log, filter)
 	ret0, _ := ret[0].(map[uint64]order.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockOrderRepoMockRecorder) Get(ctx, log, IDs interface{}) *gomock.Call {
+func (mr *MockOrderRepoMockRecorder) Get(ctx, log, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOrderRepo)(nil).Get), ctx, log, IDs)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOrderRepo)(nil).Get), ctx, log, filter)
+}
+
+// List mocks base method.
+func (m *MockOrderRepo) List(ctx context.Context, log logrus.FieldLogger, filter order.Filter, after *order.Cursor, limit uint64) ([]order.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, log, filter, after, limit)
+	ret0, _ := ret[0].([]order.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockOrderRepoMockRecorder) List(ctx, log, filter, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOrderRepo)(nil).List), ctx, log, filter, after, limit)
 }
 
 // Save mocks base method.
@@ -64,3 +79,17 @@ func (mr *MockOrderRepoMockRecorder) Save(ctx, log, order interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderRepo)(nil).Save), ctx, log, order)
 }
+
+// UpdateStatus mocks base method.
+func (m *MockOrderRepo) UpdateStatus(ctx context.Context, log logrus.FieldLogger, ID uint64, from, to order.Status) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, log, ID, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockOrderRepoMockRecorder) UpdateStatus(ctx, log, ID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepo)(nil).UpdateStatus), ctx, log, ID, from, to)
+}
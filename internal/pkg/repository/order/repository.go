@@ -2,11 +2,12 @@ package order
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/Masterminds/squirrel"
 	sq "github.com/Masterminds/squirrel"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
 	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
 	order_entity "github.com/ansakharov/lets_test/internal/pkg/entity/order"
 	"github.com/jackc/pgx/v4"
@@ -19,15 +20,25 @@ const (
 	ordersTable     = "orders"
 	itemsTable      = "items"
 	orderItemsTable = "order_items"
+	promoCodesTable = "promo_codes"
+	promoUsesTable  = "promo_code_usages"
 )
 
+// ErrStatusMismatch returned when order is not in expected status anymore.
+var ErrStatusMismatch = apperr.New(apperr.Conflict, "order status mismatch")
+
+// ErrPromoCodeUnavailable returned when promo code is missing or its usage limit reached.
+var ErrPromoCodeUnavailable = apperr.New(apperr.Unprocessable, "promo code unavailable")
+
 type Repository struct {
 	db *pgxpool.Pool
 }
 
 type OrderRepo interface {
 	Save(ctx context.Context, log logrus.FieldLogger, order *order_entity.Order) error
-	Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]order.Order, error)
+	Get(ctx context.Context, log logrus.FieldLogger, filter order.Filter) (map[uint64]order.Order, error)
+	List(ctx context.Context, log logrus.FieldLogger, filter order.Filter, after *order.Cursor, limit uint64) ([]order.Order, error)
+	UpdateStatus(ctx context.Context, log logrus.FieldLogger, ID uint64, from, to order.Status) error
 }
 
 // New instance of repository.
@@ -39,42 +50,38 @@ func New(pool *pgxpool.Pool) *Repository {
 func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, order *order_entity.Order) error {
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return fmt.Errorf("can't create tx: %s", err.Error())
+		return fmt.Errorf("can't create tx: %w", err)
 	}
 
 	query, args, err := sq.
 		Insert(ordersTable).
-		Columns("user_id", "payment_type", "created_at").
+		Columns("user_id", "status", "payment_type", "promo_code", "created_at").
 		Values(
 			order.UserID,
+			order.Status,
 			order.PaymentType,
+			order.PromoCode,
 			time.Now().Format(time.RFC3339),
 		).
-		Suffix("RETURNING id").
+		Suffix("RETURNING id, created_at").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("can't build sql: %s", err.Error())
+		return fmt.Errorf("can't build sql: %w", err)
 	}
 
 	// insert into orders table.
-	rows, err := tx.Query(ctx, query, args...)
+	var order_id uint64
+	var createdAt time.Time
+	err = tx.QueryRow(ctx, query, args...).Scan(&order_id, &createdAt)
 	if err != nil {
 		rollbackErr := tx.Rollback(ctx)
 		if rollbackErr != nil {
-			return fmt.Errorf("rollback err: %s, err: %s", rollbackErr.Error(), err.Error())
+			return fmt.Errorf("rollback err: %s, err: %w", rollbackErr.Error(), err)
 		}
 
 		return err
 	}
-	defer rows.Close()
-
-	var order_id uint64
-	for rows.Next() {
-		if err := rows.Scan(&order_id); err != nil {
-			return fmt.Errorf("can't scan order_id: %s", err.Error())
-		}
-	}
 
 	builder := sq.
 		Insert(orderItemsTable).
@@ -83,6 +90,7 @@ func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, order *or
 			"item_id",
 			"original_amount",
 			"discounted_amount",
+			"quantity",
 		)
 
 	for _, service := range order.Items {
@@ -90,13 +98,14 @@ func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, order *or
 			order_id,
 			service.ID,
 			service.Amount,
-			service.DiscountedAmount)
+			service.DiscountedAmount,
+			service.Quantity)
 	}
 	query, args, err = builder.PlaceholderFormat(sq.Dollar).ToSql()
 	if err != nil {
 		rollbackErr := tx.Rollback(ctx)
 		if rollbackErr != nil {
-			return fmt.Errorf("rollback err: %s, err: %s", rollbackErr.Error(), err.Error())
+			return fmt.Errorf("rollback err: %s, err: %w", rollbackErr.Error(), err)
 		}
 		return err
 	}
@@ -105,84 +114,285 @@ func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, order *or
 	if err != nil {
 		rollbackErr := tx.Rollback(ctx)
 		if rollbackErr != nil {
-			return fmt.Errorf("rollback err: %s, err: %s", rollbackErr.Error(), err.Error())
+			return fmt.Errorf("rollback err: %s, err: %w", rollbackErr.Error(), err)
 		}
 		return err
 	}
 
+	// record promo code usage in the same tx.
+	if order.PromoCode != "" {
+		err = usePromoCode(ctx, tx, order, order_id)
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				return fmt.Errorf("rollback err: %s, err: %w", rollbackErr.Error(), err)
+			}
+			return err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("can't commit tx: %s", err.Error())
+		return fmt.Errorf("can't commit tx: %w", err)
+	}
+
+	order.ID = order_id
+	order.CreatedAt = createdAt
+	for idx := range order.Items {
+		order.Items[idx].OrderID = order_id
 	}
 
 	return nil
 }
 
-// Get returns map of orders.
-func (r *Repository) Get(ctx context.Context, log logrus.FieldLogger, IDs []uint64) (map[uint64]order.Order, error) {
-	ordersMap := make(map[uint64]order.Order, len(IDs))
-	or := sq.Or{}
-	orOrderItems := sq.Or{}
-	for _, id := range IDs {
-		or = append(or, sq.Eq{"id": id})
-		orOrderItems = append(orOrderItems, sq.Eq{"order_id": id})
+// usePromoCode records usage of order promo code.
+// Promo code row is locked, so concurrent orders can't exceed usage limits.
+func usePromoCode(ctx context.Context, tx pgx.Tx, ord *order_entity.Order, orderID uint64) error {
+	query, args, err := sq.
+		Select("id", "max_uses", "max_uses_per_user").
+		From(promoCodesTable).
+		Where(sq.Eq{"code": ord.PromoCode}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+
+	var promoID, maxUses, maxUsesPerUser uint64
+	err = tx.QueryRow(ctx, query, args...).Scan(&promoID, &maxUses, &maxUsesPerUser)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPromoCodeUnavailable
+	}
+	if err != nil {
+		return fmt.Errorf("can't select promo code: %w", err)
+	}
+
+	query, args, err = sq.
+		Select("count(*)").
+		Column(sq.Expr("count(*) FILTER (WHERE user_id = ?)", ord.UserID)).
+		From(promoUsesTable).
+		Where(sq.Eq{"promo_code_id": promoID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+
+	var total, byUser uint64
+	if err := tx.QueryRow(ctx, query, args...).Scan(&total, &byUser); err != nil {
+		return fmt.Errorf("can't count promo code usages: %w", err)
 	}
+	if (maxUses != 0 && total >= maxUses) || (maxUsesPerUser != 0 && byUser >= maxUsesPerUser) {
+		return ErrPromoCodeUnavailable
+	}
+
+	query, args, err = sq.
+		Insert(promoUsesTable).
+		Columns("promo_code_id", "user_id", "order_id").
+		Values(promoID, ord.UserID, orderID).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build sql: %w", err)
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("can't insert promo code usage: %w", err)
+	}
+
+	return nil
+}
 
+// Get returns map of orders matching filter.
+func (r *Repository) Get(ctx context.Context, log logrus.FieldLogger, filter order.Filter) (map[uint64]order.Order, error) {
 	// build query.
 	query, args, err := sq.
-		Select("id", "user_id", "payment_type").
+		Select("id", "user_id", "status", "payment_type", "promo_code", "created_at").
 		From(ordersTable).
-		Where(or).
+		Where(filterCond(filter)).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("can't build query: %s", err.Error())
+		return nil, fmt.Errorf("can't build query: %w", err)
 	}
 
 	// get orders.
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("can't select orders: %s", err.Error())
+		return nil, fmt.Errorf("can't select orders: %w", err)
 	}
 	defer rows.Close()
 
+	ordersMap := make(map[uint64]order.Order, len(filter.IDs))
 	for rows.Next() {
 		ord := order.Order{}
-		err := rows.Scan(&ord.ID, &ord.UserID, &ord.PaymentType)
+		err := rows.Scan(&ord.ID, &ord.UserID, &ord.Status, &ord.PaymentType, &ord.PromoCode, &ord.CreatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("can't scan order: %s", err.Error())
+			return nil, fmt.Errorf("can't scan order: %w", err)
 		}
 		ordersMap[ord.ID] = ord
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select orders: %w", err)
+	}
+
+	if err := r.fillItems(ctx, ordersMap); err != nil {
+		return nil, err
+	}
+	return ordersMap, nil
+}
+
+// List returns page of orders matching filter from newest to oldest.
+// Page starts right after cursor, nil cursor means first page.
+func (r *Repository) List(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	filter order.Filter,
+	after *order.Cursor,
+	limit uint64,
+) ([]order.Order, error) {
+	cond := filterCond(filter)
+	if after != nil {
+		// keyset pagination, matches (created_at, id) ordering below.
+		cond = append(cond, sq.Expr("(created_at, id) < (?, ?)", after.CreatedAt, after.ID))
+	}
+
+	// build query.
+	query, args, err := sq.
+		Select("id", "user_id", "status", "payment_type", "promo_code", "created_at").
+		From(ordersTable).
+		Where(cond).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("can't build query: %w", err)
+	}
+
+	// get orders.
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't select orders: %w", err)
+	}
+	defer rows.Close()
+
+	IDs := make([]uint64, 0, limit)
+	ordersMap := make(map[uint64]order.Order, limit)
+	for rows.Next() {
+		ord := order.Order{}
+		err := rows.Scan(&ord.ID, &ord.UserID, &ord.Status, &ord.PaymentType, &ord.PromoCode, &ord.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan order: %w", err)
+		}
+		IDs = append(IDs, ord.ID)
+		ordersMap[ord.ID] = ord
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select orders: %w", err)
+	}
+
+	if err := r.fillItems(ctx, ordersMap); err != nil {
+		return nil, err
+	}
+
+	orders := make([]order.Order, 0, len(IDs))
+	for _, ID := range IDs {
+		orders = append(orders, ordersMap[ID])
+	}
+	return orders, nil
+}
+
+// fillItems puts items into orders.
+func (r *Repository) fillItems(ctx context.Context, ordersMap map[uint64]order.Order) error {
+	if len(ordersMap) == 0 {
+		return nil
+	}
+	IDs := make([]uint64, 0, len(ordersMap))
+	for ID := range ordersMap {
+		IDs = append(IDs, ID)
+	}
 
 	// build query
-	query, args, err = squirrel.
-		Select("order_id", "item_id", "original_amount", "discounted_amount").
+	query, args, err := sq.
+		Select("order_id", "item_id", "original_amount", "discounted_amount", "quantity").
 		From(orderItemsTable).
-		Where(orOrderItems).
+		Where(sq.Eq{"order_id": IDs}).
+		OrderBy("order_item_id").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("can't build query")
+		return fmt.Errorf("can't build query: %w", err)
 	}
 
 	// get order items
-	rows, err = r.db.Query(ctx, query, args...)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("can't select order_items: %s", err.Error())
+		return fmt.Errorf("can't select order_items: %w", err)
 	}
 	defer rows.Close()
 
 	// put items in orders.
 	for rows.Next() {
 		service := order.Item{}
-		err = rows.Scan(&service.OrderID, &service.ID, &service.Amount, &service.DiscountedAmount)
+		err = rows.Scan(&service.OrderID, &service.ID, &service.Amount, &service.DiscountedAmount, &service.Quantity)
 		if err != nil {
-			return nil, fmt.Errorf("can't scan order: %s", err.Error())
+			return fmt.Errorf("can't scan order: %w", err)
 		}
 		ord := ordersMap[service.OrderID]
 		ord.Items = append(ord.Items, service)
 
 		ordersMap[service.OrderID] = ord
 	}
-	return ordersMap, nil
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't select order_items: %w", err)
+	}
+	return nil
+}
+
+// filterCond builds WHERE condition selecting orders by filter.
+func filterCond(filter order.Filter) sq.And {
+	cond := sq.And{}
+	if len(filter.IDs) > 0 {
+		cond = append(cond, sq.Eq{"id": filter.IDs})
+	}
+	if filter.UserID != 0 {
+		cond = append(cond, sq.Eq{"user_id": filter.UserID})
+	}
+	if filter.Status != order.UnknownStatus {
+		cond = append(cond, sq.Eq{"status": filter.Status})
+	}
+	if filter.PaymentType != order.UnknownType {
+		cond = append(cond, sq.Eq{"payment_type": filter.PaymentType})
+	}
+	if !filter.CreatedFrom.IsZero() {
+		cond = append(cond, sq.GtOrEq{"created_at": filter.CreatedFrom})
+	}
+	if !filter.CreatedTo.IsZero() {
+		cond = append(cond, sq.Lt{"created_at": filter.CreatedTo})
+	}
+	return cond
+}
+
+// UpdateStatus moves order from one status to another.
+// ErrStatusMismatch returned if order is not in status "from".
+func (r *Repository) UpdateStatus(ctx context.Context, log logrus.FieldLogger, ID uint64, from, to order.Status) error {
+	query, args, err := sq.
+		Update(ordersTable).
+		Set("status", to).
+		Where(sq.Eq{"id": ID, "status": from}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can't build query: %w", err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("can't update order status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStatusMismatch
+	}
+
+	return nil
 }
@@ -0,0 +1,72 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Timed measures duration of OrderRepo calls.
+type Timed struct {
+	repo    OrderRepo
+	metrics metrics.Metrics
+}
+
+// NewTimed wraps repo.
+func NewTimed(repo OrderRepo, m metrics.Metrics) *Timed {
+	return &Timed{repo: repo, metrics: m}
+}
+
+// Save new order.
+func (t *Timed) Save(ctx context.Context, log logrus.FieldLogger, order *order.Order) error {
+	start := time.Now()
+	err := t.repo.Save(ctx, log, order)
+	t.observe("save", start, err)
+
+	return err
+}
+
+// Get returns map of orders matching filter.
+func (t *Timed) Get(ctx context.Context, log logrus.FieldLogger, filter order.Filter) (map[uint64]order.Order, error) {
+	start := time.Now()
+	orders, err := t.repo.Get(ctx, log, filter)
+	t.observe("get", start, err)
+
+	return orders, err
+}
+
+// List returns page of orders matching filter.
+func (t *Timed) List(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	filter order.Filter,
+	after *order.Cursor,
+	limit uint64,
+) ([]order.Order, error) {
+	start := time.Now()
+	orders, err := t.repo.List(ctx, log, filter, after, limit)
+	t.observe("list", start, err)
+
+	return orders, err
+}
+
+// UpdateStatus moves order from one status to another.
+func (t *Timed) UpdateStatus(ctx context.Context, log logrus.FieldLogger, ID uint64, from, to order.Status) error {
+	start := time.Now()
+	err := t.repo.UpdateStatus(ctx, log, ID, from, to)
+	t.observe("update_status", start, err)
+
+	return err
+}
+
+// observe records duration of call by method and status.
+func (t *Timed) observe(method string, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	t.metrics.ObserveSince(metrics.Labeled(metrics.OrderRepoDuration, "method", method, "status", status), start)
+}
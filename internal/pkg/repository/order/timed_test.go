@@ -0,0 +1,42 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/order"
+	orderRepo "github.com/ansakharov/lets_test/internal/pkg/repository/order"
+	mock_order "github.com/ansakharov/lets_test/internal/pkg/repository/order/mocks"
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/ansakharov/lets_test/metrics"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimed(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	ctx := context.Background()
+	log := logger.New()
+	repo := mock_order.NewMockOrderRepo(ctl)
+	recorder := metrics.NewRecorder()
+	timed := orderRepo.NewTimed(repo, recorder)
+
+	filter := order.Filter{IDs: []uint64{1}}
+	repo.EXPECT().Get(ctx, log, filter).Return(map[uint64]order.Order{}, nil).Times(2)
+	repo.EXPECT().UpdateStatus(ctx, log, uint64(1), order.CreatedStatus, order.CanceledStatus).Return(errors.New("db is down")).Times(1)
+
+	_, err := timed.Get(ctx, log, filter)
+	require.NoError(t, err)
+	_, err = timed.Get(ctx, log, filter)
+	require.NoError(t, err)
+	err = timed.UpdateStatus(ctx, log, 1, order.CreatedStatus, order.CanceledStatus)
+	require.EqualError(t, err, "db is down")
+
+	get := metrics.Labeled(metrics.OrderRepoDuration, "method", "get", "status", "ok")
+	require.Equal(t, int64(2), recorder.Observations(get))
+	update := metrics.Labeled(metrics.OrderRepoDuration, "method", "update_status", "status", "error")
+	require.Equal(t, int64(1), recorder.Observations(update))
+}
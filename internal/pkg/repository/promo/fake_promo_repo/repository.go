@@ -0,0 +1,63 @@
+package fake_promo
+
+import (
+	"context"
+
+	"github.com/ansakharov/lets_test/internal/pkg/entity/promo"
+	promoRepo "github.com/ansakharov/lets_test/internal/pkg/repository/promo"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository struct {
+	codes  map[string]promo.Code
+	usages map[uint64][]uint64
+	currID uint64
+}
+
+// New instance of repository.
+func New() *Repository {
+	return &Repository{
+		codes:  make(map[string]promo.Code),
+		usages: make(map[uint64][]uint64),
+		currID: 1,
+	}
+}
+
+// Save new promo code.
+func (r *Repository) Save(ctx context.Context, log logrus.FieldLogger, code *promo.Code) error {
+	if err := code.Validate(); err != nil {
+		return err
+	}
+	code.ID = r.currID
+	r.codes[code.Code] = *code
+	r.currID++
+
+	return nil
+}
+
+// Use records usage of promo code by user.
+func (r *Repository) Use(ctx context.Context, log logrus.FieldLogger, promoID, userID uint64) {
+	r.usages[promoID] = append(r.usages[promoID], userID)
+}
+
+// GetByCode returns promo code by its text.
+func (r *Repository) GetByCode(ctx context.Context, log logrus.FieldLogger, code string) (promo.Code, error) {
+	p, ok := r.codes[code]
+	if !ok {
+		return promo.Code{}, promoRepo.ErrPromoCodeNotFound
+	}
+
+	return p, nil
+}
+
+// Usages counts how many times promo code was used in total and by user.
+func (r *Repository) Usages(ctx context.Context, log logrus.FieldLogger, promoID, userID uint64) (uint64, uint64, error) {
+	var byUser uint64
+	for _, ID := range r.usages[promoID] {
+		if ID == userID {
+			byUser++
+		}
+	}
+
+	return uint64(len(r.usages[promoID])), byUser, nil
+}
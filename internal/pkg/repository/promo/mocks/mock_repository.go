@@ -0,0 +1,68 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/pkg/repository/promo/repository.go
+
+// Package mock_promo is a generated GoMock package.
+package mock_promo
+
+import (
+	context "context"
+	reflect "reflect"
+
+	promo "github.com/ansakharov/lets_test/internal/pkg/entity/promo"
+	gomock "github.com/golang/mock/gomock"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// MockPromoRepo is a mock of PromoRepo interface.
+type MockPromoRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromoRepoMockRecorder
+}
+
+// MockPromoRepoMockRecorder is the mock recorder for MockPromoRepo.
+type MockPromoRepoMockRecorder struct {
+	mock *MockPromoRepo
+}
+
+// NewMockPromoRepo creates a new mock instance.
+func NewMockPromoRepo(ctrl *gomock.Controller) *MockPromoRepo {
+	mock := &MockPromoRepo{ctrl: ctrl}
+	mock.recorder = &MockPromoRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromoRepo) EXPECT() *MockPromoRepoMockRecorder {
+	return m.recorder
+}
+
+// GetByCode mocks base method.
+func (m *MockPromoRepo) GetByCode(ctx context.Context, log logrus.FieldLogger, code string) (promo.Code, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", ctx, log, code)
+	ret0, _ := ret[0].(promo.Code)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockPromoRepoMockRecorder) GetByCode(ctx, log, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockPromoRepo)(nil).GetByCode), ctx, log, code)
+}
+
+// Usages mocks base method.
+func (m *MockPromoRepo) Usages(ctx context.Context, log logrus.FieldLogger, promoID, userID uint64) (uint64, uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usages", ctx, log, promoID, userID)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(uint64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Usages indicates an expected call of Usages.
+func (mr *MockPromoRepoMockRecorder) Usages(ctx, log, promoID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usages", reflect.TypeOf((*MockPromoRepo)(nil).Usages), ctx, log, promoID, userID)
+}
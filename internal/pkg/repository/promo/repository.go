@@ -0,0 +1,124 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/promo"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// tables
+	promoCodesTable      = "promo_codes"
+	promoCodeUsagesTable = "promo_code_usages"
+)
+
+// ErrPromoCodeNotFound returned when promo code doesn't exist.
+var ErrPromoCodeNotFound = apperr.New(apperr.NotFound, "promo code not found")
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+type PromoRepo interface {
+	GetByCode(ctx context.Context, log logrus.FieldLogger, code string) (promo.Code, error)
+	Usages(ctx context.Context, log logrus.FieldLogger, promoID, userID uint64) (total, byUser uint64, err error)
+}
+
+// New instance of repository.
+func New(pool *pgxpool.Pool) *Repository {
+	return &Repository{db: pool}
+}
+
+// GetByCode returns promo code by its text.
+func (r *Repository) GetByCode(ctx context.Context, log logrus.FieldLogger, code string) (promo.Code, error) {
+	query, args, err := sq.
+		Select(
+			"id",
+			"code",
+			"kind",
+			"value",
+			"valid_from",
+			"valid_to",
+			"max_uses",
+			"max_uses_per_user",
+			"item_ids",
+		).
+		From(promoCodesTable).
+		Where(sq.Eq{"code": code}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return promo.Code{}, fmt.Errorf("can't build query: %w", err)
+	}
+
+	var (
+		p                  promo.Code
+		validFrom, validTo *time.Time
+	)
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&p.ID,
+		&p.Code,
+		&p.Kind,
+		&p.Value,
+		&validFrom,
+		&validTo,
+		&p.MaxUses,
+		&p.MaxUsesPerUser,
+		&p.ItemIDs,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return promo.Code{}, ErrPromoCodeNotFound
+	}
+	if err != nil {
+		return promo.Code{}, fmt.Errorf("can't select promo code: %w", err)
+	}
+	if validFrom != nil {
+		p.ValidFrom = *validFrom
+	}
+	if validTo != nil {
+		p.ValidTo = *validTo
+	}
+	if err := checkStored(p); err != nil {
+		return promo.Code{}, err
+	}
+
+	return p, nil
+}
+
+// Usages counts how many times promo code was used in total and by user.
+func (r *Repository) Usages(ctx context.Context, log logrus.FieldLogger, promoID, userID uint64) (uint64, uint64, error) {
+	query, args, err := sq.
+		Select("count(*)").
+		Column(sq.Expr("count(*) FILTER (WHERE user_id = ?)", userID)).
+		From(promoCodeUsagesTable).
+		Where(sq.Eq{"promo_code_id": promoID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't build query: %w", err)
+	}
+
+	var total, byUser uint64
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&total, &byUser); err != nil {
+		return 0, 0, fmt.Errorf("can't count promo code usages: %w", err)
+	}
+
+	return total, byUser, nil
+}
+
+// checkStored validates promo code read from table.
+// Invalid stored code is corrupted data rather than bad request, so it's internal error.
+func checkStored(p promo.Code) error {
+	if err := p.Validate(); err != nil {
+		return apperr.Wrap(apperr.Internal, fmt.Sprintf("stored promo code %q is invalid", p.Code), err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package promo
+
+import (
+	"testing"
+
+	"github.com/ansakharov/lets_test/internal/pkg/apperr"
+	"github.com/ansakharov/lets_test/internal/pkg/entity/promo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStored(t *testing.T) {
+	cases := []struct {
+		name   string
+		code   promo.Code
+		expErr string
+	}{
+		{name: "valid", code: promo.Code{Code: "SALE15", Kind: promo.Percent, Value: 15}},
+		{
+			name:   "percent_over_100",
+			code:   promo.Code{Code: "BROKEN", Kind: promo.Percent, Value: 150},
+			expErr: `stored promo code "BROKEN" is invalid: invalid promo code: percent value must be in (0, 100]`,
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			err := checkStored(tCase.code)
+			if tCase.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tCase.expErr)
+			// corrupted row isn't client's fault.
+			require.Equal(t, apperr.Internal, apperr.KindOf(err))
+		})
+	}
+}
@@ -1,11 +1,50 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/sirupsen/logrus"
 )
 
+// Log formats.
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+)
+
+// Log outputs, any other output is treated as file path.
+const (
+	Stderr = "stderr"
+	Stdout = "stdout"
+)
+
+// Config contains settings of logger.
+type Config struct {
+	// Level is one of logrus levels: panic, fatal, error, warn, info, debug, trace.
+	Level string `yaml:"level"`
+	// Format is text or json.
+	Format string `yaml:"format"`
+	// Output is stderr, stdout or path of file to append to.
+	Output string `yaml:"output"`
+}
+
+// Validate checks level and format, all failures are listed in returned errors.
+func (c Config) Validate() []error {
+	var errs []error
+	if c.Level != "" {
+		if _, err := logrus.ParseLevel(c.Level); err != nil {
+			errs = append(errs, fmt.Errorf("can't parse log level: %w", err))
+		}
+	}
+	if c.Format != "" && c.Format != TextFormat && c.Format != JSONFormat {
+		errs = append(errs, fmt.Errorf("unknown log format %q", c.Format))
+	}
+	return errs
+}
+
 func New() *logrus.Logger {
 	return &logrus.Logger{
 		Out:       os.Stderr,
@@ -14,3 +53,77 @@ func New() *logrus.Logger {
 		Level:     logrus.DebugLevel,
 	}
 }
+
+// FromConfig gives logger configured by conf, empty settings are defaulted as in New.
+// File output stays open until process exits.
+func FromConfig(conf Config) (*logrus.Logger, error) {
+	log := New()
+
+	if conf.Level != "" {
+		level, err := logrus.ParseLevel(conf.Level)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse log level: %w", err)
+		}
+		log.Level = level
+	}
+
+	switch conf.Format {
+	case "", TextFormat:
+	case JSONFormat:
+		log.Formatter = new(logrus.JSONFormatter)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", conf.Format)
+	}
+
+	out, err := output(conf.Output)
+	if err != nil {
+		return nil, err
+	}
+	log.Out = out
+
+	return log, nil
+}
+
+// output opens writer for log output.
+func output(name string) (io.Writer, error) {
+	switch name {
+	case "", Stderr:
+		return os.Stderr, nil
+	case Stdout:
+		return os.Stdout, nil
+	}
+
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open log output: %w", err)
+	}
+	return file, nil
+}
+
+type ctxKey struct{}
+
+type requestIDKey struct{}
+
+// ToContext gives ctx carrying request-scoped log.
+func ToContext(ctx context.Context, log logrus.FieldLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext gives log stored by ToContext, fallback if there is none.
+func FromContext(ctx context.Context, fallback logrus.FieldLogger) logrus.FieldLogger {
+	if log, ok := ctx.Value(ctxKey{}).(logrus.FieldLogger); ok {
+		return log
+	}
+	return fallback
+}
+
+// WithRequestID gives ctx carrying id of request.
+func WithRequestID(ctx context.Context, ID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, ID)
+}
+
+// RequestID gives id stored by WithRequestID, empty string if there is none.
+func RequestID(ctx context.Context) string {
+	ID, _ := ctx.Value(requestIDKey{}).(string)
+	return ID
+}
@@ -0,0 +1,62 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ansakharov/lets_test/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromConfig(t *testing.T) {
+	log, err := logger.FromConfig(logger.Config{})
+	require.NoError(t, err)
+	require.Equal(t, logrus.DebugLevel, log.Level)
+	require.IsType(t, &logrus.TextFormatter{}, log.Formatter)
+	require.Equal(t, os.Stderr, log.Out)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err = logger.FromConfig(logger.Config{Level: "warn", Format: "json", Output: path})
+	require.NoError(t, err)
+	log.Info("dropped")
+	log.WithField("order_id", 1).Warn("kept")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &entry))
+	require.Equal(t, "kept", entry["msg"])
+	require.Equal(t, "warning", entry["level"])
+	require.Equal(t, float64(1), entry["order_id"])
+
+	_, err = logger.FromConfig(logger.Config{Level: "loud"})
+	require.Error(t, err)
+	_, err = logger.FromConfig(logger.Config{Format: "xml"})
+	require.EqualError(t, err, `unknown log format "xml"`)
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.Empty(t, logger.Config{}.Validate())
+	require.Empty(t, logger.Config{Level: "warn", Format: logger.JSONFormat}.Validate())
+	require.Len(t, logger.Config{Level: "loud", Format: "xml"}.Validate(), 2)
+}
+
+func TestContext(t *testing.T) {
+	fallback := logger.New()
+	require.Equal(t, fallback, logger.FromContext(context.Background(), fallback))
+
+	buf := &bytes.Buffer{}
+	log := logger.New()
+	log.Out = buf
+	ctx := logger.ToContext(context.Background(), log.WithField("request_id", "abc"))
+	logger.FromContext(ctx, fallback).Info("hello")
+	require.Contains(t, buf.String(), "request_id=abc")
+
+	require.Equal(t, "", logger.RequestID(context.Background()))
+	require.Equal(t, "abc", logger.RequestID(logger.WithRequestID(context.Background(), "abc")))
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// FunctionalCounter is counter which value is read from function,
+// e.g. monotonic count kept by library. It can't be changed directly.
+type FunctionalCounter struct {
+	value func() int64
+}
+
+// NewFunctionalCounter gives counter reading value from f.
+func NewFunctionalCounter(f func() int64) *FunctionalCounter {
+	return &FunctionalCounter{value: f}
+}
+
+// Clear panics, counter is owned by f.
+func (c *FunctionalCounter) Clear() {
+	panic("Clear called on a FunctionalCounter")
+}
+
+// Count gives current value.
+func (c *FunctionalCounter) Count() int64 {
+	return c.value()
+}
+
+// Dec panics, counter is owned by f.
+func (c *FunctionalCounter) Dec(int64) {
+	panic("Dec called on a FunctionalCounter")
+}
+
+// Inc panics, counter is owned by f.
+func (c *FunctionalCounter) Inc(int64) {
+	panic("Inc called on a FunctionalCounter")
+}
+
+// Snapshot gives read-only copy of counter.
+func (c *FunctionalCounter) Snapshot() metrics.Counter {
+	return metrics.CounterSnapshot(c.Count())
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// UpDownGauge is gauge changed by deltas, e.g. number of in-flight requests.
+type UpDownGauge struct {
+	value int64
+}
+
+// NewUpDownGauge gives gauge with zero value.
+func NewUpDownGauge() *UpDownGauge {
+	return &UpDownGauge{}
+}
+
+// Inc increases gauge by delta.
+func (g *UpDownGauge) Inc(delta int64) {
+	atomic.AddInt64(&g.value, delta)
+}
+
+// Dec decreases gauge by delta.
+func (g *UpDownGauge) Dec(delta int64) {
+	atomic.AddInt64(&g.value, -delta)
+}
+
+// Update sets gauge value.
+func (g *UpDownGauge) Update(v int64) {
+	atomic.StoreInt64(&g.value, v)
+}
+
+// Value gives current value.
+func (g *UpDownGauge) Value() int64 {
+	return atomic.LoadInt64(&g.value)
+}
+
+// Snapshot gives read-only copy of gauge.
+func (g *UpDownGauge) Snapshot() metrics.Gauge {
+	return metrics.GaugeSnapshot(g.Value())
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+var (
+	// DurationBuckets fit latencies of http requests and db queries.
+	DurationBuckets = []time.Duration{
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		25 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		250 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		2500 * time.Millisecond,
+		5 * time.Second,
+		10 * time.Second,
+	}
+	// SizeBuckets fit sizes of http responses in bytes.
+	SizeBuckets = []int64{100, 1000, 10000, 100000, 1000000}
+)
+
+// BucketHistogram counts values into fixed buckets, it's exported as prometheus histogram.
+// It implements go-metrics Histogram to be kept in go-metrics registry,
+// though only count, sum and mean of values are tracked.
+type BucketHistogram struct {
+	metrics.NilHistogram
+
+	bounds []int64
+	// counts[i] is number of values in (bounds[i-1], bounds[i]], the last one is for +Inf.
+	counts []int64
+	sum    int64
+	// unit divides values on export, e.g. durations are exported in seconds.
+	unit float64
+}
+
+// NewBucketHistogram gives histogram with sorted upper bounds of buckets.
+func NewBucketHistogram(bounds []int64, unit float64) *BucketHistogram {
+	return &BucketHistogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)+1),
+		unit:   unit,
+	}
+}
+
+// NewDurationHistogram gives histogram of durations exported in seconds.
+func NewDurationHistogram() *BucketHistogram {
+	bounds := make([]int64, 0, len(DurationBuckets))
+	for _, bound := range DurationBuckets {
+		bounds = append(bounds, int64(bound))
+	}
+	return NewBucketHistogram(bounds, float64(time.Second))
+}
+
+// NewSizeHistogram gives histogram of sizes in bytes.
+func NewSizeHistogram() *BucketHistogram {
+	return NewBucketHistogram(SizeBuckets, 1)
+}
+
+// Update adds value to histogram.
+func (h *BucketHistogram) Update(v int64) {
+	idx := sort.Search(len(h.bounds), func(i int) bool { return v <= h.bounds[i] })
+	atomic.AddInt64(&h.counts[idx], 1)
+	atomic.AddInt64(&h.sum, v)
+}
+
+// UpdateSince adds duration passed from start.
+func (h *BucketHistogram) UpdateSince(start time.Time) {
+	h.Update(int64(time.Since(start)))
+}
+
+// Count gives number of values.
+func (h *BucketHistogram) Count() int64 {
+	var count int64
+	for idx := range h.counts {
+		count += atomic.LoadInt64(&h.counts[idx])
+	}
+	return count
+}
+
+// Sum gives sum of values.
+func (h *BucketHistogram) Sum() int64 {
+	return atomic.LoadInt64(&h.sum)
+}
+
+// Mean gives average value.
+func (h *BucketHistogram) Mean() float64 {
+	count := h.Count()
+	if count == 0 {
+		return 0
+	}
+	return float64(h.Sum()) / float64(count)
+}
+
+// Clear resets histogram.
+func (h *BucketHistogram) Clear() {
+	for idx := range h.counts {
+		atomic.StoreInt64(&h.counts[idx], 0)
+	}
+	atomic.StoreInt64(&h.sum, 0)
+}
+
+// Snapshot gives copy of histogram.
+func (h *BucketHistogram) Snapshot() metrics.Histogram {
+	snapshot := NewBucketHistogram(h.bounds, h.unit)
+	for idx := range h.counts {
+		snapshot.counts[idx] = atomic.LoadInt64(&h.counts[idx])
+	}
+	snapshot.sum = h.Sum()
+	return snapshot
+}
+
+// buckets gives upper bounds in export unit with cumulative counts of values.
+func (h *BucketHistogram) buckets() ([]float64, []int64) {
+	bounds := make([]float64, 0, len(h.bounds))
+	for _, bound := range h.bounds {
+		bounds = append(bounds, float64(bound)/h.unit)
+	}
+
+	cumulative := make([]int64, len(h.counts))
+	var count int64
+	for idx := range h.counts {
+		count += atomic.LoadInt64(&h.counts[idx])
+		cumulative[idx] = count
+	}
+	return bounds, cumulative
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"strings"
+)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Labeled gives name of metric with prometheus labels passed as key, value pairs,
+// e.g. Labeled("http_requests_total", "method", "GET") is http_requests_total{method="GET"}.
+// Such names are kept in go-metrics registry as is, every label set is separate metric.
+func Labeled(base string, labels ...string) string {
+	if len(labels) < 2 {
+		return base
+	}
+
+	var b strings.Builder
+	b.WriteString(base)
+	b.WriteByte('{')
+	for idx := 0; idx+1 < len(labels); idx += 2 {
+		if idx > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[idx])
+		b.WriteString(`="`)
+		b.WriteString(labelValueEscaper.Replace(labels[idx+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// splitLabels splits name made by Labeled into base name and labels without braces.
+func splitLabels(name string) (string, string) {
+	start := strings.IndexByte(name, '{')
+	if start < 0 || !strings.HasSuffix(name, "}") {
+		return name, ""
+	}
+	return name[:start], name[start+1 : len(name)-1]
+}
+
+// withLabel adds label to labels made by Labeled and wraps them in braces.
+func withLabel(labels, key, value string) string {
+	label := key + `="` + labelValueEscaper.Replace(value) + `"`
+	if labels == "" {
+		return "{" + label + "}"
+	}
+	return "{" + labels + "," + label + "}"
+}
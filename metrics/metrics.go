@@ -1,9 +1,11 @@
 package metrics
 
 import (
-	"fmt"
+	"sync"
+	"time"
 
 	metrics "github.com/rcrowley/go-metrics"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -16,26 +18,139 @@ const (
 	SaveOrderCount   = "save_order.count"
 )
 
-func Init() {
-	metrics.Unregister(GetOrdersError)
-	metrics.MustRegister(GetOrdersError, metrics.NewCounter())
-	metrics.Unregister(GetOrdersSuccess)
-	metrics.MustRegister(GetOrdersSuccess, metrics.NewCounter())
-	metrics.Unregister(GetOrdersCount)
-	metrics.MustRegister(GetOrdersCount, metrics.NewCounter())
+// Metrics labelled with Labeled.
+const (
+	// HTTPRequests is counter of requests by route, method and status.
+	HTTPRequests = "http_requests_total"
+	// HTTPRequestDuration is latency histogram of requests by route, method and status.
+	HTTPRequestDuration = "http_request_duration_seconds"
+	// HTTPRequestsInFlight is gauge of requests being handled by route and method.
+	HTTPRequestsInFlight = "http_requests_in_flight"
+	// HTTPResponseSize is histogram of response sizes by route, method and status.
+	HTTPResponseSize = "http_response_size_bytes"
+	// HTTPPanics is counter of panics recovered in handlers by route.
+	HTTPPanics = "http_panics_total"
+
+	// OrderRepoDuration is latency histogram of OrderRepo calls by method and status.
+	OrderRepoDuration = "order_repo_duration_seconds"
+
+	// DBPoolConns is gauge of pool connections by state: acquired, idle, constructing.
+	DBPoolConns = "db_pool_conns"
+	// DBPoolMaxConns is gauge of pool size limit.
+	DBPoolMaxConns = "db_pool_max_conns"
+	// DBPoolAcquires is counter of connections acquired from pool.
+	DBPoolAcquires = "db_pool_acquires_total"
+	// DBPoolEmptyAcquires is counter of acquires which waited for connection.
+	DBPoolEmptyAcquires = "db_pool_empty_acquires_total"
+	// DBPoolCanceledAcquires is counter of acquires canceled by context.
+	DBPoolCanceledAcquires = "db_pool_canceled_acquires_total"
+	// DBConnectRetries is counter of failed attempts to connect on start.
+	DBConnectRetries = "db_connect_retries_total"
+)
+
+// Metrics receives measurements of usecases and repositories.
+type Metrics interface {
+	// IncCounter adds one to counter.
+	IncCounter(name string)
+	// ObserveSince records time passed since start to duration histogram.
+	ObserveSince(name string, start time.Time)
+}
+
+// Registry is Metrics backed by go-metrics registry.
+// Metrics are registered on first use.
+type Registry struct {
+	registry metrics.Registry
+}
 
-	metrics.Unregister(SaveOrderCount)
-	metrics.MustRegister(SaveOrderCount, metrics.NewCounter())
-	metrics.Unregister(SaveOrderError)
-	metrics.MustRegister(SaveOrderError, metrics.NewCounter())
-	metrics.Unregister(SaveOrderSuccess)
-	metrics.MustRegister(SaveOrderSuccess, metrics.NewCounter())
+// New gives Registry with its own go-metrics registry.
+func New() *Registry {
+	return &Registry{registry: metrics.NewRegistry()}
 }
 
-func IncCounter(name string) {
-	counter := metrics.Get(name).(metrics.Counter)
-	counter.Inc(1)
+// Registry gives underlying go-metrics registry, e.g. for export.
+func (r *Registry) Registry() metrics.Registry {
+	return r.registry
+}
+
+// IncCounter adds one to counter.
+func (r *Registry) IncCounter(name string) {
+	r.registry.GetOrRegister(name, metrics.NewCounter).(metrics.Counter).Inc(1)
+}
+
+// ObserveSince records time passed since start to duration histogram.
+func (r *Registry) ObserveSince(name string, start time.Time) {
+	r.registry.GetOrRegister(name, NewDurationHistogram).(*BucketHistogram).UpdateSince(start)
+}
+
+// Flush writes current values of counters to log.
+// It's called on shutdown so the last values aren't lost.
+func (r *Registry) Flush(log logrus.FieldLogger) {
+	r.registry.Each(func(name string, metric interface{}) {
+		if counter, ok := metric.(metrics.Counter); ok {
+			log.Printf("counter: %s, count: %d", name, counter.Count())
+		}
+	})
+}
 
-	fmt.Printf("counter: %s, count: %d\n", name, counter.Count())
+// Nop is Metrics that drops everything.
+type Nop struct{}
+
+// IncCounter does nothing.
+func (Nop) IncCounter(string) {}
+
+// ObserveSince does nothing.
+func (Nop) ObserveSince(string, time.Time) {}
+
+// Recorder is Metrics remembering what was reported, for tests.
+type Recorder struct {
+	mu        sync.Mutex
+	counters  map[string]int64
+	durations map[string]int64
+}
+
+// NewRecorder gives empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		counters:  make(map[string]int64),
+		durations: make(map[string]int64),
+	}
+}
+
+// IncCounter adds one to counter.
+func (r *Recorder) IncCounter(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[name]++
+}
+
+// ObserveSince counts observations of duration.
+func (r *Recorder) ObserveSince(name string, _ time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations[name]++
+}
+
+// Count gives value of counter.
+func (r *Recorder) Count(name string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[name]
+}
+
+// Counters gives copy of all bumped counters.
+func (r *Recorder) Counters() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counters := make(map[string]int64, len(r.counters))
+	for name, count := range r.counters {
+		counters[name] = count
+	}
+	return counters
+}
 
+// Observations gives number of durations recorded to histogram.
+func (r *Recorder) Observations(name string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.durations[name]
 }
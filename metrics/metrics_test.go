@@ -0,0 +1,35 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansakharov/lets_test/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := metrics.New()
+	registry.IncCounter(metrics.SaveOrderCount)
+	registry.IncCounter(metrics.SaveOrderCount)
+	registry.ObserveSince(metrics.OrderRepoDuration, time.Now())
+
+	require.Equal(t, int64(2), registry.Registry().Get(metrics.SaveOrderCount).(gometrics.Counter).Count())
+	require.Equal(t, int64(1), registry.Registry().Get(metrics.OrderRepoDuration).(gometrics.Histogram).Count())
+	// own registry doesn't touch the global one.
+	require.Nil(t, gometrics.Get(metrics.SaveOrderCount))
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := metrics.NewRecorder()
+	recorder.IncCounter(metrics.GetOrdersCount)
+	recorder.IncCounter(metrics.GetOrdersSuccess)
+	recorder.IncCounter(metrics.GetOrdersCount)
+	recorder.ObserveSince(metrics.OrderRepoDuration, time.Now())
+
+	require.Equal(t, int64(2), recorder.Count(metrics.GetOrdersCount))
+	require.Equal(t, int64(0), recorder.Count(metrics.GetOrdersError))
+	require.Equal(t, map[string]int64{metrics.GetOrdersCount: 2, metrics.GetOrdersSuccess: 1}, recorder.Counters())
+	require.Equal(t, int64(1), recorder.Observations(metrics.OrderRepoDuration))
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// PrometheusContentType is content type of prometheus text format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// quantiles exported for histograms and timers.
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+// series is metric with its labels.
+type series struct {
+	labels string
+	metric interface{}
+}
+
+// WritePrometheus writes all metrics of registry in prometheus text format.
+// Metrics with the same base name and different labels are written as one family.
+// BucketHistograms are written as histograms, other histograms and timers as summaries,
+// timers are in seconds.
+func WritePrometheus(w io.Writer, registry metrics.Registry) error {
+	families := make(map[string][]series)
+	registry.Each(func(name string, metric interface{}) {
+		base, labels := splitLabels(name)
+		base = SanitizeName(base)
+		families[base] = append(families[base], series{labels: labels, metric: metric})
+	})
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+	for _, name := range names {
+		family := families[name]
+		sort.Slice(family, func(i, j int) bool { return family[i].labels < family[j].labels })
+
+		writeType(buf, name, family[0].metric)
+		for _, s := range family {
+			writeMetric(buf, name, s.labels, s.metric)
+		}
+	}
+	return buf.Flush()
+}
+
+// writeType writes type of metric family.
+func writeType(w io.Writer, name string, metric interface{}) {
+	switch metric.(type) {
+	case metrics.Counter, metrics.Meter:
+		fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	case metrics.Gauge, metrics.GaugeFloat64:
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	case *BucketHistogram:
+		fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	case metrics.Histogram:
+		fmt.Fprintf(w, "# TYPE %s summary\n", name)
+	case metrics.Timer:
+		fmt.Fprintf(w, "# TYPE %s_seconds summary\n", name)
+	}
+}
+
+// writeMetric writes single metric, unknown metric types are skipped.
+func writeMetric(w io.Writer, name, labels string, metric interface{}) {
+	switch m := metric.(type) {
+	case metrics.Counter:
+		fmt.Fprintf(w, "%s%s %d\n", name, braces(labels), m.Count())
+	case metrics.Gauge:
+		fmt.Fprintf(w, "%s%s %d\n", name, braces(labels), m.Value())
+	case metrics.GaugeFloat64:
+		fmt.Fprintf(w, "%s%s %s\n", name, braces(labels), formatFloat(m.Value()))
+	case metrics.Meter:
+		fmt.Fprintf(w, "%s%s %d\n", name, braces(labels), m.Count())
+	case *BucketHistogram:
+		bounds, counts := m.buckets()
+		for idx, bound := range bounds {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", formatFloat(bound)), counts[idx])
+		}
+		count := counts[len(counts)-1]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, braces(labels), formatFloat(float64(m.Sum())/m.unit))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, braces(labels), count)
+	case metrics.Histogram:
+		snapshot := m.Snapshot()
+		writeSummary(w, name, labels, snapshot.Percentiles(quantiles), float64(snapshot.Sum()), snapshot.Count())
+	case metrics.Timer:
+		snapshot := m.Snapshot()
+		seconds := snapshot.Percentiles(quantiles)
+		for idx := range seconds {
+			seconds[idx] /= float64(time.Second)
+		}
+		sum := float64(snapshot.Sum()) / float64(time.Second)
+		writeSummary(w, name+"_seconds", labels, seconds, sum, snapshot.Count())
+	}
+}
+
+// writeSummary writes quantiles, sum and count of observations.
+func writeSummary(w io.Writer, name, labels string, values []float64, sum float64, count int64) {
+	for idx, q := range quantiles {
+		fmt.Fprintf(w, "%s%s %s\n", name, withLabel(labels, "quantile", formatFloat(q)), formatFloat(values[idx]))
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, braces(labels), formatFloat(sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, braces(labels), count)
+}
+
+// braces wraps non-empty labels.
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// SanitizeName makes valid prometheus metric name, e.g. get_orders.ok becomes get_orders_ok.
+func SanitizeName(name string) string {
+	var b strings.Builder
+	for idx, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if idx == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
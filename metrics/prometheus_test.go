@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeName(t *testing.T) {
+	cases := map[string]string{
+		"get_orders.ok":       "get_orders_ok",
+		"http.requests-total": "http_requests_total",
+		"pool:acquired conns": "pool:acquired_conns",
+		"2xx.responses":       "_2xx_responses",
+		"":                    "_",
+		"already_valid_name":  "already_valid_name",
+	}
+	for name, expected := range cases {
+		require.Equal(t, expected, SanitizeName(name), name)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	counter := metrics.NewCounter()
+	counter.Inc(3)
+	registry.Register("get_orders.ok", counter)
+
+	gauge := metrics.NewGauge()
+	gauge.Update(7)
+	registry.Register("pool.conns", gauge)
+
+	histogram := metrics.NewHistogram(metrics.NewUniformSample(100))
+	for _, v := range []int64{1, 2, 3, 4} {
+		histogram.Update(v)
+	}
+	registry.Register("order.items", histogram)
+
+	timer := metrics.NewTimer()
+	timer.Update(2 * time.Second)
+	registry.Register("repo.get", timer)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WritePrometheus(buf, registry))
+
+	expected := `# TYPE get_orders_ok counter
+get_orders_ok 3
+# TYPE order_items summary
+order_items{quantile="0.5"} 2.5
+order_items{quantile="0.75"} 3.75
+order_items{quantile="0.95"} 4
+order_items{quantile="0.99"} 4
+order_items_sum 10
+order_items_count 4
+# TYPE pool_conns gauge
+pool_conns 7
+# TYPE repo_get_seconds summary
+repo_get_seconds{quantile="0.5"} 2
+repo_get_seconds{quantile="0.75"} 2
+repo_get_seconds{quantile="0.95"} 2
+repo_get_seconds{quantile="0.99"} 2
+repo_get_seconds_sum 2
+repo_get_seconds_count 1
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestWritePrometheusLabels(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	for _, status := range []string{"500", "200", "200"} {
+		counter := registry.GetOrRegister(Labeled("http_requests_total", "method", "GET", "status", status), metrics.NewCounter)
+		counter.(metrics.Counter).Inc(1)
+	}
+
+	histogram := NewBucketHistogram([]int64{100, 1000}, 1)
+	for _, v := range []int64{50, 100, 500, 5000} {
+		histogram.Update(v)
+	}
+	registry.Register(Labeled("http_response_size_bytes", "route", `/say/"hi"`), histogram)
+
+	duration := NewDurationHistogram()
+	duration.Update(int64(30 * time.Millisecond))
+	registry.Register("order_repo_duration_seconds", duration)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WritePrometheus(buf, registry))
+
+	expected := `# TYPE http_requests_total counter
+http_requests_total{method="GET",status="200"} 2
+http_requests_total{method="GET",status="500"} 1
+# TYPE http_response_size_bytes histogram
+http_response_size_bytes_bucket{route="/say/\"hi\"",le="100"} 2
+http_response_size_bytes_bucket{route="/say/\"hi\"",le="1000"} 3
+http_response_size_bytes_bucket{route="/say/\"hi\"",le="+Inf"} 4
+http_response_size_bytes_sum{route="/say/\"hi\""} 5650
+http_response_size_bytes_count{route="/say/\"hi\""} 4
+# TYPE order_repo_duration_seconds histogram
+order_repo_duration_seconds_bucket{le="0.005"} 0
+order_repo_duration_seconds_bucket{le="0.01"} 0
+order_repo_duration_seconds_bucket{le="0.025"} 0
+order_repo_duration_seconds_bucket{le="0.05"} 1
+order_repo_duration_seconds_bucket{le="0.1"} 1
+order_repo_duration_seconds_bucket{le="0.25"} 1
+order_repo_duration_seconds_bucket{le="0.5"} 1
+order_repo_duration_seconds_bucket{le="1"} 1
+order_repo_duration_seconds_bucket{le="2.5"} 1
+order_repo_duration_seconds_bucket{le="5"} 1
+order_repo_duration_seconds_bucket{le="10"} 1
+order_repo_duration_seconds_bucket{le="+Inf"} 1
+order_repo_duration_seconds_sum 0.03
+order_repo_duration_seconds_count 1
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestWritePrometheusFunctional(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	acquires := int64(0)
+	registry.Register("db_pool_acquires_total", NewFunctionalCounter(func() int64 { return acquires }))
+	registry.Register(Labeled("db_pool_conns", "state", "idle"), metrics.NewFunctionalGauge(func() int64 { return 3 }))
+	acquires = 7
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WritePrometheus(buf, registry))
+
+	expected := `# TYPE db_pool_acquires_total counter
+db_pool_acquires_total 7
+# TYPE db_pool_conns gauge
+db_pool_conns{state="idle"} 3
+`
+	require.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,117 @@
+// Package migration holds versioned database schema of the service and applies it.
+//
+// Migrations are embedded sql files named like 0001_init.up.sql and 0001_init.down.sql,
+// applied ones are recorded into schema_migrations table with checksums of up files.
+package migration
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// Version of the newest embedded migration, service expects schema of this version.
+var Version = embeddedVersion()
+
+// ErrInvalidMigrations returned when migration files are malformed.
+var ErrInvalidMigrations = errors.New("invalid migrations")
+
+// Migration is single step of schema.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum of up sql, it's compared with recorded one to catch edited migrations.
+	Checksum string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Embedded gives migrations built into binary.
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads migrations from root of fsys, sorted by version.
+// Every migration must have both up and down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("can't read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: bad file name %q", ErrInvalidMigrations, entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w: bad version of %q", ErrInvalidMigrations, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("can't read migration: %w", err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d has names %q and %q", ErrInvalidMigrations, version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: version %d needs both up and down files", ErrInvalidMigrations, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// embeddedVersion gives version of the newest embedded migration.
+// Malformed embedded files are programming error, so it panics.
+func embeddedVersion() int64 {
+	migrations, err := Embedded()
+	if err != nil {
+		panic(fmt.Sprintf("can't load embedded migrations: %s", err.Error()))
+	}
+	return latest(migrations)
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
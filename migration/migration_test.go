@@ -0,0 +1,188 @@
+package migration
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// newest version is taken from file names, so added migration can't leave Version stale.
+	entries, err := fs.ReadDir(embedded, "sql")
+	require.NoError(t, err)
+	var newest int64
+	for _, entry := range entries {
+		version, err := strconv.ParseInt(strings.SplitN(entry.Name(), "_", 2)[0], 10, 64)
+		require.NoError(t, err)
+		if version > newest {
+			newest = version
+		}
+	}
+	require.Equal(t, newest, Version, "Version must be the newest migration")
+}
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		err      error
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"0010_users.up.sql":   {Data: []byte("create table users();")},
+				"0010_users.down.sql": {Data: []byte("drop table users;")},
+				"0002_init.up.sql":    {Data: []byte("create table items();")},
+				"0002_init.down.sql":  {Data: []byte("drop table items;")},
+				"README.md":           {Data: []byte("docs")},
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("create table items();")},
+			},
+			err: ErrInvalidMigrations,
+		},
+		{
+			name: "bad name",
+			fsys: fstest.MapFS{
+				"init.up.sql": {Data: []byte("create table items();")},
+			},
+			err: ErrInvalidMigrations,
+		},
+		{
+			name: "names differ",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":    {Data: []byte("create table items();")},
+				"0001_other.down.sql": {Data: []byte("drop table items;")},
+			},
+			err: ErrInvalidMigrations,
+		},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			migrations, err := Load(tCase.fsys)
+			require.ErrorIs(t, err, tCase.err)
+
+			var versions []int64
+			for _, m := range migrations {
+				require.NotEmpty(t, m.Checksum)
+				versions = append(versions, m.Version)
+			}
+			require.Equal(t, tCase.versions, versions)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init", Checksum: "a"},
+		{Version: 2, Name: "users", Checksum: "b"},
+		{Version: 3, Name: "promo", Checksum: "c"},
+	}
+	applied := func(versions ...int64) map[int64]record {
+		recs := make(map[int64]record)
+		for _, v := range versions {
+			recs[v] = record{version: v, checksum: migrations[v-1].Checksum}
+		}
+		return recs
+	}
+
+	cases := []struct {
+		name    string
+		applied map[int64]record
+		target  int64
+		up      []int64
+		down    []int64
+		err     error
+	}{
+		{
+			name:    "all pending",
+			applied: applied(),
+			target:  3,
+			up:      []int64{1, 2, 3},
+		},
+		{
+			name:    "up to date",
+			applied: applied(1, 2, 3),
+			target:  3,
+		},
+		{
+			name:    "down to version",
+			applied: applied(1, 2, 3),
+			target:  1,
+			down:    []int64{3, 2},
+		},
+		{
+			name:    "down all",
+			applied: applied(1, 2),
+			target:  0,
+			down:    []int64{2, 1},
+		},
+		{
+			name:    "gap is filled",
+			applied: applied(1, 3),
+			target:  3,
+			up:      []int64{2},
+		},
+		{
+			name:    "modified",
+			applied: map[int64]record{1: {version: 1, checksum: "edited"}},
+			target:  3,
+			err:     ErrChecksumMismatch,
+		},
+		{
+			name:    "applied without files",
+			applied: map[int64]record{1: {version: 1, checksum: "a"}, 4: {version: 4}},
+			target:  3,
+			err:     ErrUnknownVersion,
+		},
+	}
+
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			up, down, err := plan(migrations, tCase.applied, tCase.target)
+			require.ErrorIs(t, err, tCase.err)
+			require.Equal(t, tCase.up, versionsOf(up))
+			require.Equal(t, tCase.down, versionsOf(down))
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init", Checksum: "a"},
+		{Version: 2, Name: "users", Checksum: "b"},
+	}
+	applied := map[int64]record{
+		1: {version: 1, name: "init", checksum: "edited"},
+		2: {version: 2, name: "users", checksum: "b"},
+		5: {version: 5, name: "gone", checksum: "e"},
+	}
+
+	require.Equal(t, []Status{
+		{Version: 1, Name: "init", Applied: true, Modified: true},
+		{Version: 2, Name: "users", Applied: true},
+		{Version: 5, Name: "gone", Applied: true, Missing: true},
+	}, status(migrations, applied))
+}
+
+func versionsOf(migrations []Migration) []int64 {
+	var versions []int64
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	return versions
+}
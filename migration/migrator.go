@@ -0,0 +1,354 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// lockID is key of advisory lock taken while migrations are applied,
+// so instances started at once don't migrate concurrently.
+const lockID int64 = 7_437_104_021
+
+// ErrChecksumMismatch returned when applied migration file was edited.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrUnknownVersion returned when target version or applied migration has no files.
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+// Status of single migration.
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+	// AppliedAt is zero for pending migrations.
+	AppliedAt time.Time
+	// Modified tells that up file changed after migration was applied.
+	Modified bool
+	// Missing tells that applied migration has no files in binary.
+	Missing bool
+}
+
+// Report is state of database schema.
+type Report struct {
+	// TableMissing tells that schema_migrations doesn't exist, so nothing is recorded as applied.
+	TableMissing bool
+	Migrations   []Status
+}
+
+// record is row of schema_migrations.
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies migrations to database.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+	log        logrus.FieldLogger
+}
+
+// New gives Migrator of migrations, usually Embedded.
+func New(pool *pgxpool.Pool, migrations []Migration, log logrus.FieldLogger) *Migrator {
+	return &Migrator{
+		pool:       pool,
+		migrations: migrations,
+		log:        log,
+	}
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int64]record) error {
+		return m.migrate(ctx, conn, applied, latest(m.migrations))
+	})
+}
+
+// Down reverts the newest applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int64]record) error {
+		versions := appliedVersions(applied)
+		if len(versions) == 0 {
+			m.log.Print("no migrations to revert")
+			return nil
+		}
+		var target int64
+		if len(versions) > 1 {
+			target = versions[len(versions)-2]
+		}
+		return m.migrate(ctx, conn, applied, target)
+	})
+}
+
+// To applies or reverts migrations, so version is the newest applied one.
+// Version 0 reverts all migrations.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int64]record) error {
+		return m.migrate(ctx, conn, applied, version)
+	})
+}
+
+// Baseline records migrations up to version as applied without running them.
+// It adopts databases created before migrations were versioned.
+func (m *Migrator) Baseline(ctx context.Context, version int64) error {
+	if !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.withLock(ctx, func(conn *pgxpool.Conn, applied map[int64]record) error {
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			rec, ok := applied[migration.Version]
+			switch {
+			case !ok:
+				_, err := conn.Exec(ctx, "insert into schema_migrations (version, name, checksum) values ($1, $2, $3)",
+					migration.Version, migration.Name, migration.Checksum)
+				if err != nil {
+					return fmt.Errorf("can't record migration %d: %w", migration.Version, err)
+				}
+			case rec.checksum != migration.Checksum:
+				return fmt.Errorf("%w: migration %d_%s was changed after it was applied",
+					ErrChecksumMismatch, migration.Version, migration.Name)
+			default:
+				continue
+			}
+			m.log.Printf("migration %d_%s adopted", migration.Version, migration.Name)
+		}
+		return nil
+	})
+}
+
+// Status lists known and applied migrations by version.
+// It only reads schema, so it neither waits for running migration nor creates schema_migrations.
+func (m *Migrator) Status(ctx context.Context) (Report, error) {
+	var exists bool
+	err := m.pool.QueryRow(ctx, "select to_regclass('schema_migrations') is not null").Scan(&exists)
+	if err != nil {
+		return Report{}, fmt.Errorf("can't check schema_migrations: %w", err)
+	}
+	if !exists {
+		return Report{TableMissing: true, Migrations: status(m.migrations, nil)}, nil
+	}
+
+	applied, err := readApplied(ctx, m.pool)
+	if err != nil {
+		return Report{}, err
+	}
+	return Report{Migrations: status(m.migrations, applied)}, nil
+}
+
+// migrate applies and reverts migrations in order given by plan.
+func (m *Migrator) migrate(ctx context.Context, conn *pgxpool.Conn, applied map[int64]record, target int64) error {
+	up, down, err := plan(m.migrations, applied, target)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range down {
+		m.log.Printf("reverting migration %d_%s", migration.Version, migration.Name)
+		err := m.inTx(ctx, conn, migration.Down,
+			"delete from schema_migrations where version = $1", migration.Version)
+		if err != nil {
+			return fmt.Errorf("can't revert migration %d: %w", migration.Version, err)
+		}
+	}
+	for _, migration := range up {
+		m.log.Printf("applying migration %d_%s", migration.Version, migration.Name)
+		err := m.inTx(ctx, conn, migration.Up,
+			"insert into schema_migrations (version, name, checksum) values ($1, $2, $3)",
+			migration.Version, migration.Name, migration.Checksum)
+		if err != nil {
+			return fmt.Errorf("can't apply migration %d: %w", migration.Version, err)
+		}
+	}
+	if len(up) == 0 && len(down) == 0 {
+		m.log.Printf("schema is up to date, version %d", target)
+	}
+
+	return nil
+}
+
+// inTx runs migration sql and bookkeeping query atomically.
+func (m *Migrator) inTx(ctx context.Context, conn *pgxpool.Conn, migrationSQL, query string, args ...interface{}) error {
+	return conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		// no args, so statements are sent with simple protocol and may be several.
+		if _, err := tx.Exec(ctx, migrationSQL); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, query, args...)
+		return err
+	})
+}
+
+// withLock runs fn holding advisory lock, applied migrations are read under the lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn, applied map[int64]record) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("can't acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "select pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("can't take migrations lock: %w", err)
+	}
+	defer func() {
+		// lock is released on close of connection anyway.
+		if _, err := conn.Exec(context.Background(), "select pg_advisory_unlock($1)", lockID); err != nil {
+			m.log.Errorf("can't release migrations lock: %s", err.Error())
+		}
+	}()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := readApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+// ensureTable creates schema_migrations.
+func (m *Migrator) ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `
+create table if not exists schema_migrations (
+    version bigint PRIMARY KEY,
+    name text not null,
+    checksum text not null,
+    applied_at timestamptz not null default now()
+)`)
+	if err != nil {
+		return fmt.Errorf("can't create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// querier is pool or connection.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// readApplied reads schema_migrations.
+func readApplied(ctx context.Context, q querier) (map[int64]record, error) {
+	rows, err := q.Query(ctx, "select version, applied_at, name, checksum from schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("can't select schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]record)
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.version, &rec.appliedAt, &rec.name, &rec.checksum); err != nil {
+			return nil, fmt.Errorf("can't scan schema_migrations: %w", err)
+		}
+		applied[rec.version] = rec
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// plan gives migrations to apply in ascending order and to revert in descending order,
+// so target is the newest applied version.
+// Edited migrations and applied migrations without files above target are errors.
+func plan(migrations []Migration, applied map[int64]record, target int64) (up, down []Migration, err error) {
+	files := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		files[migration.Version] = migration
+		rec, ok := applied[migration.Version]
+		if ok && rec.checksum != migration.Checksum {
+			return nil, nil, fmt.Errorf("%w: migration %d_%s was changed after it was applied",
+				ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+		if !ok && migration.Version <= target {
+			up = append(up, migration)
+		}
+	}
+
+	versions := appliedVersions(applied)
+	for idx := len(versions) - 1; idx >= 0 && versions[idx] > target; idx-- {
+		migration, ok := files[versions[idx]]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: applied migration %d has no files", ErrUnknownVersion, versions[idx])
+		}
+		down = append(down, migration)
+	}
+
+	return up, down, nil
+}
+
+// status merges known and applied migrations.
+func status(migrations []Migration, applied map[int64]record) []Status {
+	statuses := make([]Status, 0, len(migrations))
+	known := make(map[int64]struct{}, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = struct{}{}
+		rec, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: rec.appliedAt,
+			Modified:  ok && rec.checksum != migration.Checksum,
+		})
+	}
+	for version, rec := range applied {
+		if _, ok := known[version]; ok {
+			continue
+		}
+		statuses = append(statuses, Status{
+			Version:   version,
+			Name:      rec.name,
+			Applied:   true,
+			AppliedAt: rec.appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses
+}
+
+// appliedVersions gives applied versions in ascending order.
+func appliedVersions(applied map[int64]record) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// latest gives version of the newest migration.
+func latest(migrations []Migration) int64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
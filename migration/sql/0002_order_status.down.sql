@@ -0,0 +1 @@
+alter table orders drop column status;
@@ -0,0 +1 @@
+alter table orders add column status smallint not null default 1;
@@ -0,0 +1,3 @@
+drop table promo_code_usages;
+drop table promo_codes;
+alter table orders drop column promo_code;
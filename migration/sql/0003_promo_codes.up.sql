@@ -0,0 +1,35 @@
+alter table orders add column promo_code text not null default '';
+
+create table promo_codes (
+    id bigserial PRIMARY KEY,
+    code text not null unique,
+    -- 1: percent, 2: fixed amount
+    kind smallint not null,
+    value integer not null,
+    valid_from timestamptz,
+    valid_to timestamptz,
+    -- 0 means unlimited
+    max_uses integer not null default 0,
+    max_uses_per_user integer not null default 0,
+    -- empty means any item
+    item_ids bigint[] not null default '{}',
+
+    CONSTRAINT known_kind CHECK (kind in (1, 2)),
+    CONSTRAINT valid_value
+        CHECK ((kind = 1 and value between 1 and 100) or (kind = 2 and value > 0))
+);
+
+create table promo_code_usages (
+    promo_code_id bigint not null,
+    user_id integer not null,
+    order_id bigint not null,
+    used_at timestamptz not null default now(),
+
+    CONSTRAINT fk_promo_code
+        FOREIGN KEY(promo_code_id)
+            REFERENCES promo_codes(id),
+
+    CONSTRAINT fk_order
+        FOREIGN KEY(order_id)
+            REFERENCES orders(id)
+);
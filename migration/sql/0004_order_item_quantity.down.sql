@@ -0,0 +1 @@
+alter table order_items drop column quantity;
@@ -0,0 +1,2 @@
+alter table order_items add column quantity integer not null default 1
+    CONSTRAINT positive_quantity CHECK (quantity > 0);
@@ -0,0 +1,11 @@
+create table idempotency_keys (
+    key text PRIMARY KEY,
+    request_hash text not null,
+    -- 0 means request is in progress
+    status_code smallint not null default 0,
+    content_type text not null default '',
+    body bytea not null default '',
+    order_id bigint not null default 0,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null
+);
@@ -0,0 +1,2 @@
+drop index orders_user_id_created_at_id_idx;
+drop index orders_created_at_id_idx;
@@ -0,0 +1,3 @@
+-- keyset pagination of orders by (created_at, id).
+create index orders_created_at_id_idx on orders (created_at desc, id desc);
+create index orders_user_id_created_at_id_idx on orders (user_id, created_at desc, id desc);